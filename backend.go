@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ---------------------------
+// Storage backends
+// ---------------------------
+
+// Backend is the key/value store a Handler runs its policies against. Keys are
+// full keys (prefix and schema version already applied) and values are encoded
+// bytes. Implementations must be safe for concurrent use.
+type Backend interface {
+	// Get returns the bytes stored under key, or ErrNotFound if it is absent.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores value under key, expiring after ttl. A ttl <= 0 means no expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// TTL returns the remaining time to live of key, ErrNotFound if it is
+	// absent, or a value <= 0 if the key does not expire.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+
+	// Exists reports whether key is present.
+	Exists(ctx context.Context, key string) (bool, error)
+
+	// Delete removes keys. Missing keys are not an error.
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// AtomicBackend is implemented by backends that support atomic conditional
+// writes. Features that need them report an error when the backend lacks them.
+type AtomicBackend interface {
+	Backend
+
+	// SetNX stores value under key only if key is absent, and reports whether it did.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
+	// CompareAndSwap replaces the value under key with next only if the current
+	// value equals prev (a nil prev means the key must be absent), and reports
+	// whether it did.
+	CompareAndSwap(ctx context.Context, key string, prev, next []byte, ttl time.Duration) (bool, error)
+}
+
+// RedisBackend adapts a go-redis client to the Backend interface. Any
+// redis.UniversalClient works: a single node, a cluster or a failover client.
+type RedisBackend struct {
+	rdb redis.UniversalClient
+}
+
+var _ AtomicBackend = (*RedisBackend)(nil)
+
+// NewRedisBackend wraps rdb as a Backend.
+func NewRedisBackend(rdb redis.UniversalClient) *RedisBackend {
+	return &RedisBackend{rdb: rdb}
+}
+
+// Client returns the underlying go-redis client.
+func (b *RedisBackend) Client() redis.UniversalClient {
+	return b.rdb
+}
+
+func (b *RedisBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	raw, err := b.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return raw, err
+}
+
+func (b *RedisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.rdb.Set(ctx, key, value, redisTTL(ttl)).Err()
+}
+
+func (b *RedisBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	d, err := b.rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case d == -2: // Key does not exist
+		return 0, ErrNotFound
+	case d < 0: // Key exists without expiry
+		return 0, nil
+	default:
+		return d, nil
+	}
+}
+
+func (b *RedisBackend) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := b.rdb.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+func (b *RedisBackend) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return b.rdb.Del(ctx, keys...).Err()
+}
+
+func (b *RedisBackend) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return b.rdb.SetNX(ctx, key, value, redisTTL(ttl)).Result()
+}
+
+// CompareAndSwap uses WATCH/MULTI so the swap aborts if another client writes
+// the key between the read and the write.
+func (b *RedisBackend) CompareAndSwap(
+	ctx context.Context,
+	key string,
+	prev, next []byte,
+	ttl time.Duration,
+) (bool, error) {
+	swapped := false
+	err := b.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		cur, err := tx.Get(ctx, key).Bytes()
+		switch {
+		case errors.Is(err, redis.Nil):
+			if prev != nil {
+				return nil
+			}
+		case err != nil:
+			return err
+		default:
+			if prev == nil || !bytes.Equal(cur, prev) {
+				return nil
+			}
+		}
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.Set(ctx, key, next, redisTTL(ttl))
+			return nil
+		})
+		if err == nil {
+			swapped = true
+		}
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return swapped, err
+}
+
+// redisTTL maps the Backend "no expiry" convention onto go-redis, where 0 means
+// no expiry and negative values have special meanings.
+func redisTTL(ttl time.Duration) time.Duration {
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
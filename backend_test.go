@@ -0,0 +1,101 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestMemoryBackend tests the Backend contract on the in-memory implementation.
+func TestMemoryBackend(t *testing.T) {
+	ctx := context.Background()
+	clock := cachetest.NewFakeClock(time.Now())
+	b := cache.NewMemoryBackendWithClock(clock)
+
+	if _, err := b.Get(ctx, "missing"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := b.TTL(ctx, "missing"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from TTL, got %v", err)
+	}
+
+	if err := b.Set(ctx, "k", []byte("v1"), 50*time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if raw, err := b.Get(ctx, "k"); err != nil || string(raw) != "v1" {
+		t.Errorf("Expected v1, got %q, %v", raw, err)
+	}
+	if ttl, err := b.TTL(ctx, "k"); err != nil || ttl != 50*time.Millisecond {
+		t.Errorf("Expected remaining TTL of 50ms, got %v, %v", ttl, err)
+	}
+
+	if ok, _ := b.SetNX(ctx, "k", []byte("other"), time.Minute); ok {
+		t.Error("Expected SetNX to fail on an existing key")
+	}
+	if ok, _ := b.CompareAndSwap(ctx, "k", []byte("stale"), []byte("v2"), time.Minute); ok {
+		t.Error("Expected CompareAndSwap to fail on a mismatched value")
+	}
+	if ok, _ := b.CompareAndSwap(ctx, "k", []byte("v1"), []byte("v2"), time.Minute); !ok {
+		t.Error("Expected CompareAndSwap to succeed on a matching value")
+	}
+
+	if err := b.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if exists, _ := b.Exists(ctx, "k"); exists {
+		t.Error("Expected key to be deleted")
+	}
+
+	_ = b.Set(ctx, "short", []byte("v"), 10*time.Millisecond)
+	clock.Advance(20 * time.Millisecond)
+	if _, err := b.Get(ctx, "short"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("Expected expired key to be gone, got %v", err)
+	}
+}
+
+// TestConcurrentMissFill tests that the locking miss policies generate once per
+// key under concurrency. It runs on MemoryBackend because the Redis mock is not
+// safe for concurrent use; the rest of the handler suite covers both backends
+// in TestHandler.
+func TestConcurrentMissFill(t *testing.T) {
+	ctx := context.Background()
+	policies := []cache.MissFillPolicy{
+		cache.MissFillSync,
+		cache.MissFillCooperative,
+		cache.MissFillCoalesce,
+	}
+	for _, p := range policies {
+		t.Run(p.String(), func(t *testing.T) {
+			h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend(), cache.WithMissFillPolicy(p))
+
+			var calls atomic.Int32
+			gen := func(_ context.Context) (string, error) {
+				calls.Add(1)
+				return "value", nil
+			}
+
+			var wg sync.WaitGroup
+			for range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					result, err := h.GetOrRefresh(ctx, "key", gen, cache.WithoutBackgroundRefresh())
+					if err != nil || result.Value != "value" {
+						t.Errorf("Unexpected result %+v, %v", result, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if n := calls.Load(); n != 1 {
+				t.Errorf("Expected generator to be called once, called %d times", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// ---------------------------
+// Background task tracking
+// ---------------------------
+
+// bgTracker counts running background tasks so callers can wait for them.
+type bgTracker struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // Closed when n drops to zero; nil while idle
+}
+
+func (t *bgTracker) start() {
+	t.mu.Lock()
+	if t.n == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.n++
+	t.mu.Unlock()
+}
+
+func (t *bgTracker) done() {
+	t.mu.Lock()
+	t.n--
+	if t.n == 0 {
+		close(t.idle)
+		t.idle = nil
+	}
+	t.mu.Unlock()
+}
+
+// wait blocks until no tasks are running or ctx is done.
+func (t *bgTracker) wait(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		idle := t.idle
+		t.mu.Unlock()
+		if idle == nil {
+			return nil
+		}
+		select {
+		case <-idle:
+			// Loop: new tasks may have started after the last one finished.
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// WithSynchronousBackground runs background work (hit refreshes, async miss
+// writes, stale rewrites) inline on the calling goroutine instead of spawning
+// a goroutine. Intended for tests that assert post-refresh state; it moves the
+// background latency onto the caller.
+func WithSynchronousBackground() Option {
+	return func(c *handlerConfig) { c.synchronousBackground = true }
+}
+
+// WaitIdle blocks until every background task started by the handler (and by
+// any other view of its Core) has finished, or ctx is done. Tasks started
+// while waiting are waited for too.
+func (h *Handler[T]) WaitIdle(ctx context.Context) error {
+	return h.core.bg.wait(ctx)
+}
+
+// background runs fn as a tracked background task, inline when
+// WithSynchronousBackground is set.
+func (h *Handler[T]) background(fn func()) {
+	if h.config.synchronousBackground {
+		fn()
+		return
+	}
+	h.core.bg.start()
+	go func() {
+		defer h.core.bg.done()
+		fn()
+	}()
+}
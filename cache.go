@@ -4,36 +4,43 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-// Handler is the Redis cache handler.
+var _ Cache[any] = (*Handler[any])(nil)
+
+// Handler is the Redis cache handler. Handlers created with New own their Core;
+// use Typed to create several handlers that share one.
 type Handler[T any] struct {
-	config           handlerConfig
-	localLocks       *KeyedMutex
-	lastRefreshByKey map[string]time.Time
-	lastRefreshMu    sync.Mutex
+	config  *handlerConfig // Snapshot used by the current call; see pin
+	sliding slidingState   // Sliding expiration resolved for the current call
+	live    *liveConfig
+	core    *Core
 }
 
-// New creates a new cache Handler[T].
+// New creates a new cache Handler[T] backed by the Redis client rdb.
 func New[T any](rdb *redis.Client, opts ...Option) (*Handler[T], error) {
-	config, err := loadHandlerConfig(rdb)
+	return NewWithBackend[T](NewRedisBackend(rdb), opts...)
+}
+
+// NewWithBackend creates a new cache Handler[T] that stores entries in b, for
+// example a MemoryBackend in tests or a custom adapter for another store.
+func NewWithBackend[T any](b Backend, opts ...Option) (*Handler[T], error) {
+	core, err := NewCoreWithBackend(b, opts...)
 	if err != nil {
 		return nil, err
 	}
+	return Typed[T](core, "")
+}
 
-	for _, o := range opts {
-		o(config)
-	}
-	return &Handler[T]{
-		config:           *config,
-		localLocks:       NewKeyedMutex(),
-		lastRefreshByKey: make(map[string]time.Time),
-	}, nil
+// NewWithConfig creates a new cache Handler[T] backed by rdb and configured by
+// cfg. Unlike earlier versions of New it never reads .env files or CACHE_*
+// variables; use FromEnv to build cfg from the environment. The final
+// configuration, including opts, is checked with Config.Validate.
+func NewWithConfig[T any](rdb *redis.Client, cfg Config, opts ...Option) (*Handler[T], error) {
+	return New[T](rdb, append([]Option{WithConfig(cfg)}, opts...)...)
 }
 
 func WithPrefix(prefix string) Option {
@@ -69,39 +76,35 @@ func WithDefaultErrorPolicy(p ErrorPolicy) Option {
 	return func(c *handlerConfig) { c.defaultErrorPolicy = p }
 }
 
+// WithDecodeFailurePolicy sets how the handler reacts to cached values that
+// cannot be decoded into T. See DecodeFailurePolicy.
+func WithDecodeFailurePolicy(p DecodeFailurePolicy) Option {
+	return func(c *handlerConfig) { c.decodeFailurePolicy = p }
+}
+
 // WithStaleDataTTL sets how long stale data is kept for stale-while-revalidate policy.
 func WithStaleDataTTL(ttl time.Duration) Option {
 	return func(c *handlerConfig) { c.staleDataTTL = ttl }
 }
 
 // WithRefreshAheadThreshold sets the default threshold for refresh-ahead policy.
-// Value should be between 0.0 and 1.0 (e.g., 0.2 = refresh when 20% TTL remaining).
+// Value must be between 0.0 and 1.0 (e.g., 0.2 = refresh when 20% TTL remaining);
+// the constructor rejects anything else with ErrInvalidConfig.
 func WithRefreshAheadThreshold(threshold float64) Option {
-	return func(c *handlerConfig) {
-		if threshold >= 0.0 && threshold <= 1.0 {
-			c.defaultRefreshAheadThreshold = threshold
-		}
-	}
+	return func(c *handlerConfig) { c.defaultRefreshAheadThreshold = threshold }
 }
 
 // WithProbabilisticBeta sets the beta parameter for probabilistic refresh policy.
+// Beta must be > 0.
 func WithProbabilisticBeta(beta float64) Option {
-	return func(c *handlerConfig) {
-		if beta > 0 {
-			c.defaultProbabilisticBeta = beta
-		}
-	}
+	return func(c *handlerConfig) { c.defaultProbabilisticBeta = beta }
 }
 
 // WithRefreshOlderThanAge sets the default age threshold for the HitRefreshOlderThan
 // policy. A background refresh is triggered when a cached entry's age (originalTTL
-// minus remaining Redis TTL) exceeds d.
+// minus remaining Redis TTL) exceeds d. d must be >= 0.
 func WithRefreshOlderThanAge(d time.Duration) Option {
-	return func(c *handlerConfig) {
-		if d > 0 {
-			c.defaultRefreshOlderThanAge = d
-		}
-	}
+	return func(c *handlerConfig) { c.defaultRefreshOlderThanAge = d }
 }
 
 // WithCallRefreshOlderThanAge overrides the age threshold for HitRefreshOlderThan
@@ -171,14 +174,12 @@ func WithStaleCheckTimeout(timeout time.Duration) CallOption {
 // ---------------------------
 
 func (h *Handler[T]) fullKey(key string) string {
-	if h.config.prefix == "" {
-		return key
-	}
-	return h.config.prefix + ":" + key
+	return h.versionedKey(h.config.schemaVersion, key)
 }
 
 // Set writes a value with TTL.
 func (h *Handler[T]) Set(ctx context.Context, key string, value T, opts ...CallOption) error {
+	h = h.pin()
 	var co callOpts
 	for _, o := range opts {
 		o(&co)
@@ -188,46 +189,96 @@ func (h *Handler[T]) Set(ctx context.Context, key string, value T, opts ...CallO
 	if ttl <= 0 {
 		ttl = h.config.defaultTTL
 	}
+	h.applySliding(ttl, co)
+	return h.set(ctx, key, value, ttl)
+}
 
-	var err error
-	var b []byte
+// set writes value under key as a value produced now; see setVersion.
+func (h *Handler[T]) set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	return h.setVersion(ctx, key, value, ttl, h.newVersion())
+}
 
+// setVersion encodes value and writes it under key with the given TTL (after
+// jitter and the sliding lifetime cap), recording the write for cooldown and
+// read-your-writes accounting. With versioned writes, version is the time the
+// value started being produced and an entry with a newer version is kept.
+func (h *Handler[T]) setVersion(ctx context.Context, key string, value T, ttl time.Duration, version int64) error {
 	k := h.fullKey(key)
-	b, err = json.Marshal(value)
+	b, err := json.Marshal(value)
 	if err != nil {
-		return fmt.Errorf("marshal: %w", err)
+		return &EncodeError{Key: k, Err: err}
 	}
-	if err = h.config.rdb.Set(ctx, k, b, ttl).Err(); err != nil {
-		return fmt.Errorf("redis set: %w", err)
+	written, err := h.write(ctx, k, b, h.capLifetime(h.jitterTTL(k, ttl)), version)
+	if err != nil || !written {
+		return err
 	}
-	h.setLastRefreshNow(k) // For cooldown accounting
+	return h.afterWrite(ctx, k)
+}
+
+// afterWrite starts the sliding lifetime of a freshly written fullKey and
+// records the write for cooldown, read-your-writes and hot key accounting.
+func (h *Handler[T]) afterWrite(ctx context.Context, fullKey string) error {
+	if err := h.startLifetime(ctx, fullKey); err != nil {
+		return err
+	}
+	h.setLastRefreshNow(fullKey) // For cooldown accounting
+	h.recordWrite(fullKey)
+	h.core.hotKeys.forget(fullKey)
 	return nil
 }
 
-// Get fetches a value from Redis into T.
-func (h *Handler[T]) Get(ctx context.Context, key string) (Result[T], error) {
-	var zero T
+// Delete removes a key and its stale copy (see MissFillStaleOrSync). It also
+// voids any fill lease on the key, so an in-flight MissFillLease fill is dropped.
+func (h *Handler[T]) Delete(ctx context.Context, key string) error {
+	return h.pin().invalidate(ctx, key)
+}
+
+// invalidate deletes key and its companion keys without pinning.
+func (h *Handler[T]) invalidate(ctx context.Context, key string) error {
 	k := h.fullKey(key)
-	cmd := h.config.rdb.Get(ctx, k)
-	if err := cmd.Err(); err != nil {
-		if errors.Is(err, redis.Nil) {
-			return Result[T]{Value: zero, FromCache: false}, redis.Nil
-		}
-		return Result[T]{Value: zero}, fmt.Errorf("redis get: %w", err)
+	if err := h.config.backend.Delete(ctx, k, h.fullKey(key+":stale"), lifetimeKey(k), leaseKey(k)); err != nil {
+		return &BackendError{Op: "delete", Key: k, Err: err}
 	}
-	var err error
-	var raw []byte
+	h.recordWrite(k)
+	h.core.hotKeys.forget(k)
+	return nil
+}
 
-	raw, err = cmd.Bytes()
-	if err != nil {
-		return Result[T]{Value: zero}, fmt.Errorf("bytes: %w", err)
+// Get fetches a value from Redis into T.
+// It returns ErrNotFound when the key is absent, a *BackendError when Redis
+// fails, and a *DecodeError when the stored bytes cannot be decoded into T.
+// With WithSchemaMigration, a miss first tries to upgrade an older-version entry.
+func (h *Handler[T]) Get(ctx context.Context, key string) (Result[T], error) {
+	return h.pin().lookup(ctx, key)
+}
+
+// lookup is Get on an already pinned handler.
+func (h *Handler[T]) lookup(ctx context.Context, key string) (Result[T], error) {
+	h.core.reads.touch(h.fullKey(key), h.now())
+	if h.config.hotKeyWindow > 0 {
+		return h.hotLookup(ctx, key)
 	}
+	res, err := h.get(ctx, key)
+	h.emitLookup(h.fullKey(key), err)
+	return res, err
+}
+
+// get is Get without observer events, used for internal double-checks.
+func (h *Handler[T]) get(ctx context.Context, key string) (Result[T], error) {
+	var zero T
+	fullKey := h.fullKey(key)
+	raw, err := h.getMainRaw(ctx, fullKey)
 	var v T
-	if err = json.Unmarshal(raw, &v); err != nil {
-		return Result[T]{Value: zero}, fmt.Errorf("unmarshal: %w", err)
+	if err == nil {
+		v, err = h.decode(ctx, fullKey, raw)
 	}
-
-	return Result[T]{Value: v, FromCache: true, CachedAt: time.Now()}, nil
+	if errors.Is(err, ErrNotFound) && len(h.config.migrations) > 0 {
+		v, err = h.migrateFromOlder(ctx, key)
+	}
+	if err != nil {
+		return Result[T]{Value: zero, FromCache: false}, err
+	}
+	return Result[T]{Value: v, FromCache: true, CachedAt: h.now()}, nil
 }
 
 // ---------------------------
@@ -239,6 +290,31 @@ func (h *Handler[T]) GetOrRefresh(
 	key string,
 	gen Generator[T],
 	opts ...CallOption,
+) (Result[T], error) {
+	return h.pin().getOrRefresh(ctx, key, entryGenerator(gen), opts)
+}
+
+// GetOrRefreshEntry is GetOrRefresh for generators that decide the TTL of
+// each value. Entry.TTL overrides WithTTL and the handler default whenever it
+// is positive, and Entry.NoCache returns the value without storing it. Both
+// are honoured by every miss-fill policy and by background refreshes.
+// Hit-refresh thresholds (refresh-ahead, older-than) are still measured
+// against the call or handler TTL.
+func (h *Handler[T]) GetOrRefreshEntry(
+	ctx context.Context,
+	key string,
+	gen EntryGenerator[T],
+	opts ...CallOption,
+) (Result[T], error) {
+	return h.pin().getOrRefresh(ctx, key, gen, opts)
+}
+
+// getOrRefresh implements GetOrRefresh and GetOrRefreshEntry on a pinned handler.
+func (h *Handler[T]) getOrRefresh(
+	ctx context.Context,
+	key string,
+	gen EntryGenerator[T],
+	opts []CallOption,
 ) (Result[T], error) {
 	var co callOpts
 	for _, o := range opts {
@@ -248,6 +324,7 @@ func (h *Handler[T]) GetOrRefresh(
 	if ttl <= 0 {
 		ttl = h.config.defaultTTL
 	}
+	h.applySliding(ttl, co)
 
 	missFill := h.config.defaultMissFillPolicy
 	if co.overrideMissFillPolicy != nil {
@@ -270,14 +347,16 @@ func (h *Handler[T]) GetOrRefresh(
 	var res Result[T]
 	var err error
 
+	gen = h.observeGenerator(h.fullKey(key), gen)
+
 	// 1) Try cache
-	if res, err = h.Get(ctx, key); err == nil {
+	if res, err = h.lookup(ctx, key); err == nil {
 		// Handle hit-based refresh policies
 		if !co.disableHitRefresh {
 			h.handleHitRefresh(ctx, key, ttl, gen, hitRefresh, co)
 		}
 		return res, nil
-	} else if !errors.Is(err, redis.Nil) {
+	} else if !errors.Is(err, ErrNotFound) {
 		var zero T
 		return Result[T]{Value: zero}, err
 	}
@@ -301,6 +380,10 @@ func (h *Handler[T]) GetOrRefresh(
 		res, err = h.missFailFast(ctx, key)
 	case MissFillCooperative:
 		res, err = h.missCooperativeRefresh(ctx, key, ttl, gen)
+	case MissFillCoalesce:
+		res, err = h.missCoalesce(ctx, key, ttl, gen)
+	case MissFillLease:
+		res, err = h.missLease(ctx, key, ttl, gen)
 	default:
 		res, err = h.missSyncWriteThenReturn(ctx, key, ttl, gen)
 	}
@@ -308,9 +391,9 @@ func (h *Handler[T]) GetOrRefresh(
 	// Record creation time for probabilistic refresh after a successful fill
 	if err == nil && hitRefresh == HitRefreshProbabilistic {
 		fullKey := h.fullKey(key)
-		h.lastRefreshMu.Lock()
-		h.lastRefreshByKey[fullKey+"@created"] = time.Now()
-		h.lastRefreshMu.Unlock()
+		h.core.lastRefreshMu.Lock()
+		h.core.lastRefreshByKey[fullKey+"@created"] = h.now()
+		h.core.lastRefreshMu.Unlock()
 	}
 
 	// 3) Apply error policy — never suppress ErrCacheMiss (that is an intentional signal)
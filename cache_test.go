@@ -1,44 +1,103 @@
 package cache_test //nolint:cyclop // TODO: fix later the average complexity for the package cache_test is 19.000000, max is 10.000000
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
 	"github.com/go-redis/redismock/v9"
-	"github.com/redis/go-redis/v9"
 )
 
-// TestHandler tests the core functionality of the Handler[T] type.
-//
-//nolint:gocognit,gocyclo,cyclop  // TODO: fix later cognitive complexity 62 of func `TestHandler` is high (> 20) // TODO: cyclomatic complexity 32 of func `TestHandler` is high (> 30) calculated cyclomatic complexity for function TestHandler is 32, max is 30
+// handlerSuiteBackend is one storage backend TestHandler runs against. Redis
+// is mocked, so subtests register the commands they issue with expect and
+// check them with verify; both are no-ops on the in-memory backend, which is
+// exercised for real.
+type handlerSuiteBackend struct {
+	backend cache.Backend
+	mock    redismock.ClientMock // nil for the in-memory backend
+}
+
+// handlerSuiteBackends lists the backends TestHandler covers.
+var handlerSuiteBackends = []struct {
+	name string
+	new  func() handlerSuiteBackend
+}{
+	{"Redis", func() handlerSuiteBackend {
+		rdb, mock := redismock.NewClientMock()
+		return handlerSuiteBackend{backend: cache.NewRedisBackend(rdb), mock: mock}
+	}},
+	{"Memory", func() handlerSuiteBackend {
+		return handlerSuiteBackend{backend: cache.NewMemoryBackend()}
+	}},
+}
+
+// expect registers Redis mock expectations.
+func (b handlerSuiteBackend) expect(f func(mock redismock.ClientMock)) {
+	if b.mock != nil {
+		f(b.mock)
+	}
+}
+
+// seed makes the next read of key return raw.
+func (b handlerSuiteBackend) seed(t *testing.T, key, raw string) {
+	t.Helper()
+	if b.mock != nil {
+		b.mock.ExpectGet(key).SetVal(raw)
+		return
+	}
+	if err := b.backend.Set(context.Background(), key, []byte(raw), 0); err != nil {
+		t.Fatalf("Seeding %q failed: %v", key, err)
+	}
+}
+
+// verify fails the test if registered Redis expectations were not met.
+func (b handlerSuiteBackend) verify(t *testing.T) {
+	t.Helper()
+	if b.mock == nil {
+		return
+	}
+	if err := b.mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+// TestHandler tests the core functionality of the Handler[T] type against
+// every backend in handlerSuiteBackends.
 func TestHandler(t *testing.T) {
+	for _, sb := range handlerSuiteBackends {
+		t.Run(sb.name, func(t *testing.T) { testHandlerSuite(t, sb.new) })
+	}
+}
+
+//nolint:gocognit,gocyclo,cyclop,maintidx // TODO: split the suite into smaller functions
+func testHandlerSuite(t *testing.T, newBackend func() handlerSuiteBackend) {
 	var err error
 	var result cache.Result[string]
 
-	// Create a mock Redis client
-	rdb, mock := redismock.NewClientMock()
-
 	ctx := context.Background()
 
 	t.Run("Set and Get", func(t *testing.T) {
-		// Clear any previous expectations
-		mock.ClearExpect()
+		b := newBackend()
 
 		// Create a Handler for string type
-		h, _ := cache.New[string](rdb,
+		h, _ := cache.NewWithBackend[string](b.backend,
 			cache.WithPrefix("test"),
 			cache.WithDefaultTTL(1*time.Minute),
 		)
 
 		// Set up mock expectations
-		mock.ExpectSet("test:key1", []byte(`"test-value"`), time.Minute).SetVal("OK")
-		mock.ExpectGet("test:key1").SetVal(`"test-value"`)
+		b.expect(func(mock redismock.ClientMock) {
+			mock.ExpectSet("test:key1", []byte(`"test-value"`), time.Minute).SetVal("OK")
+			mock.ExpectGet("test:key1").SetVal(`"test-value"`)
+		})
 
 		// Test successful Set and Get
 		key := "key1"
@@ -61,30 +120,28 @@ func TestHandler(t *testing.T) {
 			t.Error("Expected non-zero CachedAt")
 		}
 
-		// Verify all expectations were met
-		if err = mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Redis mock expectations not met: %v", err)
-		}
+		b.verify(t)
 	})
 
 	t.Run("Get Miss", func(t *testing.T) {
-		// Clear any previous expectations
-		mock.ClearExpect()
+		b := newBackend()
 
 		// Create a Handler for string type
-		h, _ := cache.New[string](rdb,
+		h, _ := cache.NewWithBackend[string](b.backend,
 			cache.WithPrefix("test"),
 			cache.WithDefaultTTL(1*time.Minute),
 		)
 
 		// Set up mock expectations
-		mock.ExpectGet("test:missing-key").RedisNil()
+		b.expect(func(mock redismock.ClientMock) {
+			mock.ExpectGet("test:missing-key").RedisNil()
+		})
 
 		// Test cache miss
 		key := "missing-key"
 		result, err = h.Get(ctx, key)
-		if !errors.Is(err, redis.Nil) {
-			t.Errorf("Expected redis.Nil error, got %v", err)
+		if !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound error, got %v", err)
 		}
 		if result.FromCache {
 			t.Error("Expected FromCache to be false")
@@ -93,21 +150,64 @@ func TestHandler(t *testing.T) {
 			t.Errorf("Expected zero value, got %q", result.Value)
 		}
 
-		// Verify all expectations were met
-		if err = mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Redis mock expectations not met: %v", err)
+		b.verify(t)
+	})
+
+	t.Run("Get Backend Error", func(t *testing.T) {
+		b := newBackend()
+		if b.mock == nil {
+			t.Skip("MemoryBackend reads never fail")
+		}
+
+		h, _ := cache.NewWithBackend[string](b.backend, cache.WithPrefix("test"))
+
+		b.mock.ExpectGet("test:broken").SetErr(errors.New("connection refused"))
+
+		_, err = h.Get(ctx, "broken")
+		var backendErr *cache.BackendError
+		if !errors.As(err, &backendErr) {
+			t.Fatalf("Expected *cache.BackendError, got %v", err)
 		}
+		if backendErr.Op != "get" || backendErr.Key != "test:broken" {
+			t.Errorf("Unexpected BackendError fields: op=%q key=%q", backendErr.Op, backendErr.Key)
+		}
+
+		b.verify(t)
+	})
+
+	t.Run("GetOrRefresh Generator Error", func(t *testing.T) {
+		b := newBackend()
+
+		h, _ := cache.NewWithBackend[string](b.backend, cache.WithPrefix("test"))
+
+		b.expect(func(mock redismock.ClientMock) {
+			mock.ExpectGet("test:gen-fail").RedisNil()
+			mock.ExpectGet("test:gen-fail").RedisNil()
+		})
+
+		genErr := errors.New("upstream down")
+		_, err = h.GetOrRefresh(ctx, "gen-fail", func(_ context.Context) (string, error) {
+			return "", genErr
+		})
+		var generatorErr *cache.GeneratorError
+		if !errors.As(err, &generatorErr) {
+			t.Fatalf("Expected *cache.GeneratorError, got %v", err)
+		}
+		if !errors.Is(err, genErr) {
+			t.Errorf("Expected GeneratorError to wrap the generator error, got %v", err)
+		}
+
+		b.verify(t)
 	})
 
 	t.Run("Set JSON Error", func(t *testing.T) {
-		// Clear any previous expectations
-		mock.ClearExpect()
+		b := newBackend()
 
 		// Test Set with unmarshalable value
 		type badType struct {
 			Ch chan int // JSON marshaling fails for channels
 		}
-		hBad, _ := cache.New[badType](rdb)
+		hBad, _ := cache.NewWithBackend[badType](b.backend)
 		err = hBad.Set(ctx, "bad-key", badType{Ch: make(chan int)})
 		if err == nil {
 			t.Error("Expected JSON marshal error, got nil")
@@ -117,20 +217,23 @@ func TestHandler(t *testing.T) {
 		if !errors.As(err, &unsupportedErr) {
 			t.Errorf("Expected JSON marshal error, got %v", err)
 		}
+		var encodeErr *cache.EncodeError
+		if !errors.As(err, &encodeErr) {
+			t.Errorf("Expected *cache.EncodeError, got %T", err)
+		}
 	})
 
 	t.Run("Get JSON Error", func(t *testing.T) {
-		// Clear any previous expectations
-		mock.ClearExpect()
+		b := newBackend()
 
 		// Create a Handler for string type
-		h, _ := cache.New[string](rdb,
+		h, _ := cache.NewWithBackend[string](b.backend,
 			cache.WithPrefix("test"),
 			cache.WithDefaultTTL(1*time.Minute),
 		)
 
-		// Set up mock expectations
-		mock.ExpectGet("test:invalid-json").SetVal("invalid-json-data")
+		// Store invalid JSON data
+		b.seed(t, "test:invalid-json", "invalid-json-data")
 
 		// Test Get with invalid JSON data
 		key := "invalid-json"
@@ -138,14 +241,17 @@ func TestHandler(t *testing.T) {
 		if err == nil {
 			t.Error("Expected JSON unmarshal error, got nil")
 		}
+		var decodeErr *cache.DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("Expected *cache.DecodeError, got %T", err)
+		} else if decodeErr.Key != "test:invalid-json" {
+			t.Errorf("Expected DecodeError key %q, got %q", "test:invalid-json", decodeErr.Key)
+		}
 		if result.FromCache {
 			t.Error("Expected FromCache to be false")
 		}
 
-		// Verify all expectations were met
-		if err = mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Redis mock expectations not met: %v", err)
-		}
+		b.verify(t)
 	})
 
 	// t.Run("GetOrRefresh SyncWriteThenReturn", func(t *testing.T) {
@@ -226,18 +332,19 @@ func TestHandler(t *testing.T) {
 	// })
 
 	t.Run("GetOrRefresh Cache Hit", func(t *testing.T) {
-		// Clear any previous expectations
-		mock.ClearExpect()
+		b := newBackend()
 
 		// Create a Handler for string type
-		h, _ := cache.New[string](rdb,
+		h, _ := cache.NewWithBackend[string](b.backend,
 			cache.WithPrefix("test"),
 			cache.WithDefaultTTL(1*time.Minute),
 		)
 
 		// Set up mock expectations
-		mock.ExpectSet("test:hit-key", []byte(`"cached-value"`), time.Minute).SetVal("OK")
-		mock.ExpectGet("test:hit-key").SetVal(`"cached-value"`)
+		b.expect(func(mock redismock.ClientMock) {
+			mock.ExpectSet("test:hit-key", []byte(`"cached-value"`), time.Minute).SetVal("OK")
+			mock.ExpectGet("test:hit-key").SetVal(`"cached-value"`)
+		})
 
 		key := "hit-key"
 		value := "cached-value"
@@ -251,7 +358,8 @@ func TestHandler(t *testing.T) {
 			return "should-not-be-called", nil
 		}
 
-		result, err = h.GetOrRefresh(ctx, key, gen)
+		// Background refresh is covered below; here the hit must not generate.
+		result, err = h.GetOrRefresh(ctx, key, gen, cache.WithoutBackgroundRefresh())
 		if err != nil {
 			t.Fatalf("GetOrRefresh failed: %v", err)
 		}
@@ -265,26 +373,27 @@ func TestHandler(t *testing.T) {
 			t.Errorf("Expected generator not to be called, called %d times", generateCount)
 		}
 
-		// Verify all expectations were met
-		if err = mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Redis mock expectations not met: %v", err)
-		}
+		b.verify(t)
 	})
 
 	t.Run("GetOrRefresh Background Refresh", func(t *testing.T) {
-		// Clear any previous expectations
-		mock.ClearExpect()
+		b := newBackend()
 
-		// Create a Handler for string type
-		h, _ := cache.New[string](rdb,
+		// Create a Handler for string type; background work runs inline.
+		h, _ := cache.NewWithBackend[string](b.backend,
 			cache.WithPrefix("test"),
 			cache.WithDefaultTTL(1*time.Minute),
 			cache.WithBackgroundRefreshTimeout(2*time.Second),
+			cache.WithSynchronousBackground(),
 		)
 
 		// Set up mock expectations
-		mock.ExpectSet("test:refresh-key", []byte(`"initial-value"`), time.Minute).SetVal("OK")
-		mock.ExpectGet("test:refresh-key").SetVal(`"initial-value"`)
+		b.expect(func(mock redismock.ClientMock) {
+			mock.ExpectSet("test:refresh-key", []byte(`"initial-value"`), time.Minute).SetVal("OK")
+			mock.ExpectGet("test:refresh-key").SetVal(`"initial-value"`)
+			mock.ExpectSet("test:refresh-key", []byte(`"updated-value"`), time.Minute).SetVal("OK")
+			mock.ExpectGet("test:refresh-key").SetVal(`"updated-value"`)
+		})
 
 		key := "refresh-key"
 		initialValue := "initial-value"
@@ -312,30 +421,63 @@ func TestHandler(t *testing.T) {
 			t.Errorf("Expected value %q, got %q", initialValue, result.Value)
 		}
 
-		// For this test, we just verify the cache hit works
-		// Background refresh testing would require more complex mock setup
-		if generateCount != 0 {
-			t.Errorf("Expected generator not to be called on cache hit, called %d times", generateCount)
+		// The hit is served from cache and refreshed in the background.
+		if generateCount != 1 {
+			t.Errorf("Expected generator to be called once by the background refresh, called %d times", generateCount)
+		}
+		if result, err = h.Get(ctx, key); err != nil || result.Value != "updated-value" {
+			t.Errorf("Expected the refreshed value, got %q, %v", result.Value, err)
 		}
 
-		// Verify all expectations were met
-		if err = mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Redis mock expectations not met: %v", err)
+		b.verify(t)
+	})
+
+	t.Run("GetOrRefresh Async Miss", func(t *testing.T) {
+		b := newBackend()
+
+		h, _ := cache.NewWithBackend[string](b.backend,
+			cache.WithPrefix("test"),
+			cache.WithDefaultTTL(1*time.Minute),
+			cache.WithMissFillPolicy(cache.MissFillAsync),
+		)
+
+		b.expect(func(mock redismock.ClientMock) {
+			mock.ExpectGet("test:async-key").RedisNil()
+			mock.ExpectExists("test:async-key").SetVal(0)
+			mock.ExpectSet("test:async-key", []byte(`"async-value"`), time.Minute).SetVal("OK")
+			mock.ExpectGet("test:async-key").SetVal(`"async-value"`)
+		})
+
+		result, err = h.GetOrRefresh(ctx, "async-key", func(_ context.Context) (string, error) {
+			return "async-value", nil
+		})
+		if err != nil || result.FromCache || result.Value != "async-value" {
+			t.Fatalf("Expected the generated value, got %+v, %v", result, err)
+		}
+		if err = h.WaitIdle(ctx); err != nil {
+			t.Fatalf("WaitIdle failed: %v", err)
 		}
+		if result, err = h.Get(ctx, "async-key"); err != nil || result.Value != "async-value" {
+			t.Errorf("Expected the background write to store the value, got %q, %v", result.Value, err)
+		}
+
+		b.verify(t)
 	})
 
 	t.Run("Refresh Cooldown", func(t *testing.T) {
-		// Clear any previous expectations
-		mock.ClearExpect()
+		b := newBackend()
 
 		// Create a Handler for string type
-		h, _ := cache.New[string](rdb,
+		h, _ := cache.NewWithBackend[string](b.backend,
 			cache.WithRefreshCooldown(500*time.Millisecond),
+			cache.WithSynchronousBackground(),
 		)
 
 		// Set up mock expectations
-		mock.ExpectSet("cooldown-key", []byte(`"initial"`), 5*time.Minute).SetVal("OK")
-		mock.ExpectGet("cooldown-key").SetVal(`"initial"`)
+		b.expect(func(mock redismock.ClientMock) {
+			mock.ExpectSet("cooldown-key", []byte(`"initial"`), 5*time.Minute).SetVal("OK")
+			mock.ExpectGet("cooldown-key").SetVal(`"initial"`)
+		})
 
 		key := "cooldown-key"
 		generateCount := 0
@@ -361,10 +503,7 @@ func TestHandler(t *testing.T) {
 			t.Errorf("Expected generator not to be called on cache hit, called %d times", generateCount)
 		}
 
-		// Verify all expectations were met
-		if err = mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Redis mock expectations not met: %v", err)
-		}
+		b.verify(t)
 	})
 
 	// t.Run("Concurrent GetOrRefresh", func(t *testing.T) {
@@ -454,9 +593,399 @@ func TestKeyedMutex(t *testing.T) {
 			t.Error("Expected second TryLock to fail")
 		}
 		unlock1()
-		_, ok3 := km.TryLock(key)
+		unlock3, ok3 := km.TryLock(key)
 		if !ok3 {
 			t.Error("Expected TryLock to succeed after unlock")
 		}
+		unlock3()
+	})
+
+	// Test LockContext
+	t.Run("LockContext", func(t *testing.T) {
+		unlock1 := km.Lock(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := km.LockContext(ctx, key); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected LockContext to time out, got %v", err)
+		}
+
+		unlock1()
+		// The timed-out waiter must not have acquired the lock in the background.
+		unlock2, ok := km.TryLock(key)
+		if !ok {
+			t.Fatal("Expected lock to be free after the timed-out LockContext")
+		}
+		unlock2()
+
+		unlock3, err := km.LockContext(context.Background(), key)
+		if err != nil {
+			t.Errorf("Expected LockContext to succeed, got %v", err)
+		}
+		unlock3()
+	})
+}
+
+// TestDecodeFailurePolicy tests how undecodable cached values are handled.
+func TestDecodeFailurePolicy(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	ctx := context.Background()
+
+	gen := func(_ context.Context) (string, error) { return "fresh", nil }
+
+	t.Run("Miss regenerates and overwrites", func(t *testing.T) {
+		mock.ClearExpect()
+
+		var events []cache.Event
+		h, _ := cache.New[string](rdb,
+			cache.WithPrefix("test"),
+			cache.WithDefaultTTL(time.Minute),
+			cache.WithDecodeFailurePolicy(cache.DecodeFailureMiss),
+			cache.WithObserver(func(e cache.Event) {
+				if e.Kind == cache.EventDecodeFailure {
+					events = append(events, e)
+				}
+			}),
+		)
+
+		mock.ExpectGet("test:poison").SetVal("not-json")
+		mock.ExpectGet("test:poison").SetVal("not-json")
+		mock.ExpectSet("test:poison", []byte(`"fresh"`), time.Minute).SetVal("OK")
+
+		result, err := h.GetOrRefresh(ctx, "poison", gen)
+		if err != nil {
+			t.Fatalf("GetOrRefresh failed: %v", err)
+		}
+		if result.Value != "fresh" || result.FromCache {
+			t.Errorf("Expected regenerated value, got %+v", result)
+		}
+		if len(events) != 2 || events[0].Key != "test:poison" {
+			t.Errorf("Expected two decode failure events for test:poison, got %+v", events)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Redis mock expectations not met: %v", err)
+		}
+	})
+
+	t.Run("Delete removes entry and misses", func(t *testing.T) {
+		mock.ClearExpect()
+
+		h, _ := cache.New[string](rdb,
+			cache.WithPrefix("test"),
+			cache.WithDecodeFailurePolicy(cache.DecodeFailureDelete),
+		)
+
+		mock.ExpectGet("test:poison").SetVal("not-json")
+		mock.ExpectDel("test:poison").SetVal(1)
+
+		_, err := h.Get(ctx, "poison")
+		if !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Redis mock expectations not met: %v", err)
+		}
+	})
+}
+
+// TestSchemaVersion tests version-scoped keys and migration from older versions.
+func TestSchemaVersion(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	ctx := context.Background()
+
+	t.Run("Version embedded in key", func(t *testing.T) {
+		mock.ClearExpect()
+
+		h, _ := cache.New[string](rdb,
+			cache.WithPrefix("test"),
+			cache.WithDefaultTTL(time.Minute),
+			cache.WithSchemaVersion(2),
+		)
+
+		mock.ExpectSet("test:v2:key", []byte(`"value"`), time.Minute).SetVal("OK")
+		if err := h.Set(ctx, "key", "value"); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Redis mock expectations not met: %v", err)
+		}
+	})
+
+	t.Run("Migration from older version", func(t *testing.T) {
+		mock.ClearExpect()
+
+		type user struct {
+			FullName string `json:"full_name"`
+		}
+		h, _ := cache.New[user](rdb,
+			cache.WithPrefix("test"),
+			cache.WithSchemaVersion(2),
+			cache.WithSchemaMigration(1, func(old json.RawMessage) (json.RawMessage, error) {
+				var v1 struct {
+					Name string `json:"name"`
+				}
+				if err := json.Unmarshal(old, &v1); err != nil {
+					return nil, err
+				}
+				return json.Marshal(user{FullName: v1.Name})
+			}),
+		)
+
+		mock.ExpectGet("test:v2:u1").RedisNil()
+		mock.ExpectGet("test:v1:u1").SetVal(`{"name":"Ada"}`)
+		mock.ExpectPTTL("test:v1:u1").SetVal(30 * time.Second)
+		mock.ExpectSet("test:v2:u1", []byte(`{"full_name":"Ada"}`), 30*time.Second).SetVal("OK")
+
+		result, err := h.Get(ctx, "u1")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if !result.FromCache || result.Value.FullName != "Ada" {
+			t.Errorf("Expected migrated cached value, got %+v", result)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Redis mock expectations not met: %v", err)
+		}
+	})
+
+	t.Run("Migration writes like Set", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](
+			cache.WithSchemaVersion(2),
+			cache.WithSchemaMigration(1, func(old json.RawMessage) (json.RawMessage, error) { return old, nil }),
+			cache.WithVersionedWrites(),
+			cache.WithTTLJitter(0.5),
+			cache.WithSlidingExpiration(time.Hour),
+		)
+		_ = h.Backend.Set(ctx, "v1:k", []byte(`"old"`), 30*time.Second)
+
+		if r, err := h.Get(ctx, "k"); err != nil || r.Value != "old" {
+			t.Fatalf("Expected the migrated value, got %q, %v", r.Value, err)
+		}
+		if ttl, _ := h.Backend.TTL(ctx, "v2:k"); ttl != 30*time.Second {
+			t.Errorf("Expected the old TTL without jitter, got %v", ttl)
+		}
+		if raw, _ := h.Backend.Get(ctx, "v2:k"); !bytes.HasPrefix(raw, []byte("\x00v")) {
+			t.Errorf("Expected a version header, got %q", raw)
+		}
+		if ok, _ := h.Backend.Exists(ctx, "{v2:k}:lifetime"); !ok {
+			t.Error("Expected the sliding lifetime to start")
+		}
+	})
+}
+
+// TestMissFillCoalesce tests that concurrent misses share one in-flight generation.
+func TestMissFillCoalesce(t *testing.T) {
+	ctx := context.Background()
+	coalesce := cache.WithMissFillPolicy(cache.MissFillCoalesce)
+
+	// blockingGen returns a generator that signals started and then blocks until
+	// release is closed.
+	blockingGen := func(value string, err error) (gen cache.Generator[string], started, release chan struct{}) {
+		started, release = make(chan struct{}), make(chan struct{})
+		var once sync.Once
+		return func(_ context.Context) (string, error) {
+			once.Do(func() { close(started) })
+			<-release
+			return value, err
+		}, started, release
+	}
+
+	t.Run("Generates once", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend(), coalesce)
+		var calls atomic.Int32
+		gen := func(_ context.Context) (string, error) {
+			calls.Add(1)
+			return "shared", nil
+		}
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if res, err := h.GetOrRefresh(ctx, "key", gen, cache.WithoutBackgroundRefresh()); err != nil || res.Value != "shared" {
+					t.Errorf("Unexpected result %+v, %v", res, err)
+				}
+			}()
+		}
+		wg.Wait()
+		if n := calls.Load(); n != 1 {
+			t.Errorf("Expected the generator to be called once, called %d times", n)
+		}
+	})
+
+	t.Run("Fans out errors", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend(), coalesce)
+		genErr := errors.New("upstream down")
+		gen, started, release := blockingGen("", genErr)
+
+		errs := make(chan error, 5)
+		for range 5 {
+			go func() {
+				_, err := h.GetOrRefresh(ctx, "key", gen)
+				errs <- err
+			}()
+		}
+		<-started
+		close(release)
+		for range 5 {
+			if err := <-errs; !errors.Is(err, genErr) {
+				t.Errorf("Expected shared generator error, got %v", err)
+			}
+		}
+	})
+
+	t.Run("Waiter cancellation", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend(), coalesce)
+		gen, started, release := blockingGen("value", nil)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := h.GetOrRefresh(ctx, "key", gen)
+			done <- err
+		}()
+		<-started
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := h.GetOrRefresh(waitCtx, "key", gen); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected cancelled waiter to return its context error, got %v", err)
+		}
+
+		close(release)
+		if err := <-done; err != nil {
+			t.Errorf("Expected the remaining waiter to succeed, got %v", err)
+		}
+	})
+
+	t.Run("Shared fills time out", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend(), coalesce,
+			cache.WithBackgroundRefreshTimeout(10*time.Millisecond))
+		hung := func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		if _, err := h.GetOrRefresh(ctx, "key", hung); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the detached fill to hit the background timeout, got %v", err)
+		}
+	})
+
+	t.Run("Serialises with sync fills", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend(), coalesce)
+		gen, started, release := blockingGen("coalesced", nil)
+		go func() { _, _ = h.GetOrRefresh(ctx, "key", gen) }()
+		<-started
+
+		syncDone := make(chan cache.Result[string], 1)
+		go func() {
+			res, _ := h.GetOrRefresh(ctx, "key", func(_ context.Context) (string, error) {
+				t.Error("Expected the sync fill to wait for the coalesced one")
+				return "sync", nil
+			}, cache.WithCallMissFillPolicy(cache.MissFillSync), cache.WithoutBackgroundRefresh())
+			syncDone <- res
+		}()
+		close(release)
+		if res := <-syncDone; res.Value != "coalesced" {
+			t.Errorf("Expected the coalesced value, got %q", res.Value)
+		}
+	})
+
+	t.Run("Shared across views", func(t *testing.T) {
+		core, _ := cache.NewCoreWithBackend(cache.NewMemoryBackend(), coalesce)
+		viewA, _ := cache.Typed[string](core, "users")
+		viewB, _ := cache.Typed[string](core, "users")
+		gen, started, release := blockingGen("from A", nil)
+		go func() { _, _ = viewA.GetOrRefresh(ctx, "key", gen) }()
+		<-started
+
+		done := make(chan cache.Result[string], 1)
+		go func() {
+			res, _ := viewB.GetOrRefresh(ctx, "key", func(_ context.Context) (string, error) {
+				t.Error("Expected view B to share view A's fill")
+				return "from B", nil
+			}, cache.WithoutBackgroundRefresh())
+			done <- res
+		}()
+		close(release)
+		if res := <-done; res.Value != "from A" {
+			t.Errorf("Expected view A's value, got %q", res.Value)
+		}
+	})
+}
+
+// TestReadClient tests that reads go to the read client except within the read-your-writes window.
+func TestReadClient(t *testing.T) {
+	primary, primaryMock := redismock.NewClientMock()
+	replica, replicaMock := redismock.NewClientMock()
+	ctx := context.Background()
+
+	h, _ := cache.New[string](primary,
+		cache.WithPrefix("test"),
+		cache.WithDefaultTTL(time.Minute),
+		cache.WithReadClient(replica),
+		cache.WithReadYourWrites(time.Hour),
+	)
+
+	replicaMock.ExpectGet("test:other").SetVal(`"from-replica"`)
+	primaryMock.ExpectSet("test:mine", []byte(`"written"`), time.Minute).SetVal("OK")
+	primaryMock.ExpectGet("test:mine").SetVal(`"written"`)
+
+	result, err := h.Get(ctx, "other")
+	if err != nil || result.Value != "from-replica" {
+		t.Fatalf("Expected replica read, got %+v, %v", result, err)
+	}
+
+	if err = h.Set(ctx, "mine", "written"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	result, err = h.Get(ctx, "mine")
+	if err != nil || result.Value != "written" {
+		t.Fatalf("Expected primary read after local write, got %+v, %v", result, err)
+	}
+
+	if err = primaryMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Primary mock expectations not met: %v", err)
+	}
+	if err = replicaMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Replica mock expectations not met: %v", err)
+	}
+
+	t.Run("Reads return to the replica after the window", func(t *testing.T) {
+		replica := cache.NewMemoryBackend()
+		h, _ := cachetest.NewHandler[string](cache.WithReadBackend(replica), cache.WithReadYourWrites(time.Minute))
+		_ = replica.Set(ctx, "key", []byte(`"replica"`), 0)
+		_ = h.Set(ctx, "key", "primary")
+
+		if r, _ := h.Get(ctx, "key"); r.Value != "primary" {
+			t.Errorf("Expected a primary read within the window, got %q", r.Value)
+		}
+		h.Clock.Advance(time.Minute)
+		if r, _ := h.Get(ctx, "key"); r.Value != "replica" {
+			t.Errorf("Expected a replica read after the window, got %q", r.Value)
+		}
+	})
+
+	t.Run("Background writes check the primary", func(t *testing.T) {
+		replica := cache.NewMemoryBackend()
+		h, _ := cachetest.NewHandler[string](
+			cache.WithReadBackend(replica),
+			cache.WithReadYourWrites(time.Minute),
+			cache.WithMissFillPolicy(cache.MissFillAsync),
+			cache.WithDecodeFailurePolicy(cache.DecodeFailureMiss),
+		)
+		_ = h.Set(ctx, "key", "old")
+		_ = replica.Set(ctx, "key", []byte(`"old"`), 0) // The replica lags a delete on the primary
+		_ = h.Backend.Delete(ctx, "key")
+
+		_, _ = h.GetOrRefresh(ctx, "key", func(context.Context) (string, error) {
+			h.Clock.Advance(time.Minute) // Reads go to the replica again by the time of the write
+			return "new", nil
+		})
+		if raw, _ := h.Backend.Get(ctx, "key"); string(raw) != `"new"` {
+			t.Errorf("Expected the background write to reach the primary, got %q", raw)
+		}
 	})
 }
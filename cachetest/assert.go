@@ -0,0 +1,41 @@
+package cachetest
+
+import "testing"
+
+// AssertHit fails the test unless key was found in the cache at least once.
+func AssertHit(t testing.TB, r *Recorder, key string) {
+	t.Helper()
+	if r.Hits(key) == 0 {
+		t.Errorf("cachetest: expected a cache hit for %q, got none (requested: %v)", key, r.Requested())
+	}
+}
+
+// AssertMiss fails the test unless key was looked up and missed at least once.
+func AssertMiss(t testing.TB, r *Recorder, key string) {
+	t.Helper()
+	if r.Misses(key) == 0 {
+		t.Errorf("cachetest: expected a cache miss for %q, got none (requested: %v)", key, r.Requested())
+	}
+}
+
+// AssertGenerated fails the test unless the generator ran exactly times for key.
+func AssertGenerated(t testing.TB, r *Recorder, key string, times int) {
+	t.Helper()
+	if n := r.Generated(key); n != times {
+		t.Errorf("cachetest: expected generator to run %d times for %q, ran %d times", times, key, n)
+	}
+}
+
+// AssertNotGenerated fails the test if the generator ran for key.
+func AssertNotGenerated(t testing.TB, r *Recorder, key string) {
+	t.Helper()
+	AssertGenerated(t, r, key, 0)
+}
+
+// AssertRequested fails the test unless key was looked up at least once.
+func AssertRequested(t testing.TB, r *Recorder, key string) {
+	t.Helper()
+	if r.Hits(key)+r.Misses(key) == 0 {
+		t.Errorf("cachetest: expected %q to be requested (requested: %v)", key, r.Requested())
+	}
+}
@@ -0,0 +1,90 @@
+// Package cachetest provides helpers for testing code that uses the cashcov
+// cache package without a Redis server or real waits.
+package cachetest
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a manually advanced cache.TimerClock. The zero value is not
+// usable; create one with NewFakeClock.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // Signalled when a timer is added
+	now     time.Time
+	waiters []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFakeClock returns a FakeClock frozen at start.
+func NewFakeClock(start time.Time) *FakeClock {
+	c := &FakeClock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d, firing the timers that are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.fire()
+	c.mu.Unlock()
+}
+
+// Set moves the clock to t, firing the timers that are due.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.fire()
+	c.mu.Unlock()
+}
+
+// After returns a channel that receives the clock's time once Advance or Set
+// moves it d past now. A d <= 0 fires at once.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeTimer{at: c.now.Add(d), ch: ch})
+	c.cond.Broadcast()
+	return ch
+}
+
+// BlockUntil blocks until at least n timers are waiting to fire. Call it
+// before Advance to make sure the code under test has reached its wait.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// fire delivers and drops the timers due at c.now. c.mu must be held.
+func (c *FakeClock) fire() {
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
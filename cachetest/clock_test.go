@@ -0,0 +1,120 @@
+package cachetest_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestFakeClock tests time-dependent policies without real waits.
+func TestFakeClock(t *testing.T) {
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	gen := func(_ context.Context) (string, error) {
+		calls.Add(1)
+		return "fresh", nil
+	}
+
+	t.Run("Refresh cooldown", func(t *testing.T) {
+		calls.Store(0)
+		clock := cachetest.NewFakeClock(time.Unix(0, 0))
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackendWithClock(clock),
+			cache.WithClock(clock),
+			cache.WithRefreshCooldown(time.Minute),
+		)
+
+		_ = h.Set(ctx, "key", "initial")
+		if _, err := h.GetOrRefresh(ctx, "key", gen); err != nil {
+			t.Fatalf("GetOrRefresh failed: %v", err)
+		}
+		if err := h.WaitIdle(ctx); err != nil {
+			t.Fatalf("WaitIdle failed: %v", err)
+		}
+		if n := calls.Load(); n != 0 {
+			t.Errorf("Expected no refresh within the cooldown, got %d", n)
+		}
+
+		clock.Advance(2 * time.Minute)
+		if _, err := h.GetOrRefresh(ctx, "key", gen); err != nil {
+			t.Fatalf("GetOrRefresh failed: %v", err)
+		}
+		if err := h.WaitIdle(ctx); err != nil {
+			t.Fatalf("WaitIdle failed: %v", err)
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("Expected one refresh after the cooldown, got %d", n)
+		}
+	})
+
+	t.Run("Refresh ahead", func(t *testing.T) {
+		calls.Store(0)
+		clock := cachetest.NewFakeClock(time.Unix(0, 0))
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackendWithClock(clock),
+			cache.WithClock(clock),
+			cache.WithDefaultTTL(10*time.Minute),
+			cache.WithDefaultHitRefreshPolicy(cache.HitRefreshAhead),
+			cache.WithRefreshAheadThreshold(0.2),
+			cache.WithSynchronousBackground(),
+		)
+
+		_ = h.Set(ctx, "key", "initial")
+		clock.Advance(5 * time.Minute)
+		if _, err := h.GetOrRefresh(ctx, "key", gen); err != nil {
+			t.Fatalf("GetOrRefresh failed: %v", err)
+		}
+		if n := calls.Load(); n != 0 {
+			t.Errorf("Expected no refresh with half the TTL left, got %d", n)
+		}
+
+		clock.Advance(4 * time.Minute)
+		result, err := h.GetOrRefresh(ctx, "key", gen)
+		if err != nil {
+			t.Fatalf("GetOrRefresh failed: %v", err)
+		}
+		if !result.CachedAt.Equal(clock.Now()) {
+			t.Errorf("Expected CachedAt from the fake clock, got %v", result.CachedAt)
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("Expected one refresh with 10%% of the TTL left, got %d", n)
+		}
+		if result, _ = h.Get(ctx, "key"); result.Value != "fresh" {
+			t.Errorf("Expected refreshed value, got %q", result.Value)
+		}
+	})
+
+	t.Run("Timers", func(t *testing.T) {
+		clock := cachetest.NewFakeClock(time.Unix(0, 0))
+		soon, later := clock.After(time.Minute), clock.After(time.Hour)
+		if at := <-clock.After(0); !at.Equal(time.Unix(0, 0)) {
+			t.Errorf("Expected a zero timer to fire at once, got %v", at)
+		}
+
+		clock.BlockUntil(2)
+		clock.Advance(time.Minute)
+		select {
+		case at := <-soon:
+			if !at.Equal(time.Unix(60, 0)) {
+				t.Errorf("Expected the timer to carry the clock time, got %v", at)
+			}
+		default:
+			t.Error("Expected the 1m timer to fire")
+		}
+		select {
+		case <-later:
+			t.Error("Expected the 1h timer to keep waiting")
+		default:
+		}
+
+		clock.Set(time.Unix(3600, 0))
+		select {
+		case <-later:
+		default:
+			t.Error("Expected Set to fire the 1h timer")
+		}
+	})
+}
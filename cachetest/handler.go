@@ -0,0 +1,51 @@
+package cachetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+)
+
+// Handler is a *cache.Handler[T] backed by an in-memory store, with the pieces
+// a test needs to control and inspect it. It embeds the real handler, so code
+// under test sees exactly the production API.
+type Handler[T any] struct {
+	*cache.Handler[T]
+
+	Backend  *cache.MemoryBackend // The in-memory store; inspect or flush it directly
+	Clock    *FakeClock           // Drives the handler and the store; advance it instead of sleeping
+	Recorder *Recorder            // Every event the handler emitted
+}
+
+// NewHandler returns an in-memory Handler[T] driven by a FakeClock starting at
+// the current time, with background work run synchronously so post-refresh
+// state can be asserted as soon as a call returns. opts are applied after the
+// test wiring, so they may override anything except the backend.
+func NewHandler[T any](opts ...cache.Option) (*Handler[T], error) {
+	clock := NewFakeClock(time.Now())
+	backend := cache.NewMemoryBackendWithClock(clock)
+	rec := NewRecorder()
+
+	all := append([]cache.Option{
+		cache.WithClock(clock),
+		cache.WithObserver(rec.Observe),
+		cache.WithSynchronousBackground(),
+	}, opts...)
+	h, err := cache.NewWithBackend[T](backend, all...)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler[T]{Handler: h, Backend: backend, Clock: clock, Recorder: rec}, nil
+}
+
+// Seed writes value under key and returns h for chaining, failing tb if the
+// write fails. Set emits no events, so seeding does not show up in the Recorder.
+func (h *Handler[T]) Seed(tb testing.TB, key string, value T, opts ...cache.CallOption) *Handler[T] {
+	tb.Helper()
+	if err := h.Set(context.Background(), key, value, opts...); err != nil {
+		tb.Fatalf("cachetest: seed %q: %v", key, err)
+	}
+	return h
+}
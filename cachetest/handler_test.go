@@ -0,0 +1,64 @@
+package cachetest_test
+
+import (
+	"context"
+	"testing"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestHandler tests the in-memory handler, recorder and assertions.
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+
+	h, err := cachetest.NewHandler[string](cache.WithDefaultHitRefreshPolicy(cache.HitRefreshNone))
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	h.Seed(t, "seeded", "value")
+
+	gen := func(_ context.Context) (string, error) { return "generated", nil }
+
+	result, err := h.GetOrRefresh(ctx, "seeded", gen)
+	if err != nil || result.Value != "value" {
+		t.Fatalf("Expected seeded value, got %+v, %v", result, err)
+	}
+	cachetest.AssertHit(t, h.Recorder, "seeded")
+	cachetest.AssertNotGenerated(t, h.Recorder, "seeded")
+
+	result, err = h.GetOrRefresh(ctx, "fresh", gen)
+	if err != nil || result.Value != "generated" {
+		t.Fatalf("Expected generated value, got %+v, %v", result, err)
+	}
+	cachetest.AssertMiss(t, h.Recorder, "fresh")
+	cachetest.AssertGenerated(t, h.Recorder, "fresh", 1)
+
+	if got := h.Recorder.Requested(); len(got) != 2 || got[0] != "seeded" || got[1] != "fresh" {
+		t.Errorf("Unexpected requested keys: %v", got)
+	}
+
+	if _, err = h.Backend.Get(ctx, "fresh"); err != nil {
+		t.Errorf("Expected generated value to be stored in the backend: %v", err)
+	}
+
+	t.Run("Background refresh runs synchronously", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string]()
+		h.Seed(t, "key", "old")
+
+		if _, err := h.GetOrRefresh(ctx, "key", gen); err != nil {
+			t.Fatalf("GetOrRefresh failed: %v", err)
+		}
+		if got := h.Recorder.BackgroundRefreshes("key"); got != 1 {
+			t.Errorf("Expected one background refresh, got %d", got)
+		}
+		if result, _ := h.Get(ctx, "key"); result.Value != "generated" {
+			t.Errorf("Expected refreshed value, got %q", result.Value)
+		}
+	})
+
+	h.Recorder.Reset()
+	if len(h.Recorder.Events()) != 0 {
+		t.Error("Expected Reset to discard events")
+	}
+}
@@ -0,0 +1,84 @@
+package cachetest
+
+import (
+	"sync"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+)
+
+// Recorder collects handler events for assertions. Register it with
+// cache.WithObserver(rec.Observe); NewHandler does this for you. Keys are full
+// keys, i.e. including any prefix or schema version.
+type Recorder struct {
+	mu     sync.Mutex
+	events []cache.Event
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Observe records e. It satisfies cache.Observer.
+func (r *Recorder) Observe(e cache.Event) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+// Events returns a copy of every recorded event in emission order.
+func (r *Recorder) Events() []cache.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]cache.Event, len(r.events))
+	copy(out, r.events)
+	return out
+}
+
+// Requested returns the keys looked up through Get or GetOrRefresh, in order,
+// with repeats.
+func (r *Recorder) Requested() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []string
+	for _, e := range r.events {
+		if e.Kind == cache.EventHit || e.Kind == cache.EventMiss {
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+// Count returns how many events of kind were recorded for key.
+func (r *Recorder) Count(kind cache.EventKind, key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, e := range r.events {
+		if e.Kind == kind && e.Key == key {
+			n++
+		}
+	}
+	return n
+}
+
+// Hits returns how many lookups of key found it in the cache.
+func (r *Recorder) Hits(key string) int { return r.Count(cache.EventHit, key) }
+
+// Misses returns how many lookups of key did not find it.
+func (r *Recorder) Misses(key string) int { return r.Count(cache.EventMiss, key) }
+
+// Generated returns how many times the generator ran for key, foreground or background.
+func (r *Recorder) Generated(key string) int { return r.Count(cache.EventGenerate, key) }
+
+// BackgroundRefreshes returns how many background refreshes ran for key.
+func (r *Recorder) BackgroundRefreshes(key string) int {
+	return r.Count(cache.EventBackgroundRefresh, key)
+}
+
+// Reset discards every recorded event.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.events = nil
+	r.mu.Unlock()
+}
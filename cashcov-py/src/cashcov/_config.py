@@ -1,6 +1,6 @@
 """Environment-variable configuration loader for cashcov.
 
-Mirrors the Go ``loadHandlerConfig`` approach: reads numeric tuning parameters
+Mirrors the Go ``cache.FromEnv`` helper: reads numeric tuning parameters
 from the process environment (and optionally a ``.env`` file) so that
 deployment-level defaults can be controlled without code changes.
 
@@ -0,0 +1,56 @@
+package cache
+
+import "time"
+
+// Clock is the time source a Handler reads from. Swap it with WithClock to
+// drive cooldowns, deduplication windows, refresh-ahead checks and CachedAt
+// from a controllable clock in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// TimerClock is a Clock that can also deliver timers. Periodic and retrying
+// work, such as Warmer.Run, waits on the handler clock when it implements
+// TimerClock and on real timers otherwise; cachetest.FakeClock implements it.
+type TimerClock interface {
+	Clock
+	// After returns a channel that receives the clock's time once d has
+	// elapsed on the clock.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the default Clock backed by time.Now.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock sets the time source used everywhere the handler reads time.
+// See cachetest.FakeClock for a controllable implementation.
+func WithClock(c Clock) Option {
+	return func(cfg *handlerConfig) {
+		if c != nil {
+			cfg.clock = c
+		}
+	}
+}
+
+// now returns the current time according to the handler's clock.
+func (h *Handler[T]) now() time.Time {
+	return h.config.clock.Now()
+}
+
+// since returns the time elapsed since t according to the handler's clock.
+func (h *Handler[T]) since(t time.Time) time.Duration {
+	return h.config.clock.Now().Sub(t)
+}
+
+// after returns a channel that fires once d has elapsed on the handler's
+// clock, or on a real timer when the clock is not a TimerClock.
+func (h *Handler[T]) after(d time.Duration) <-chan time.Time {
+	if tc, ok := h.config.clock.(TimerClock); ok {
+		return tc.After(d)
+	}
+	return time.After(d)
+}
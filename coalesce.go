@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ---------------------------
+// In-process request coalescing
+// ---------------------------
+
+// flightGroup coalesces concurrent fills of the same key into a single
+// in-flight execution whose result is shared by every caller. One group lives
+// on the Core, so every view of it coalesces; flights are keyed by the value
+// type too, so views of different types never share a result.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[flightKey]*flightCall
+}
+
+// flightKey identifies one flight: a full cache key filled for one type.
+type flightKey struct {
+	key string
+	typ reflect.Type
+}
+
+// flightCall is one in-flight execution and the callers waiting on it.
+type flightCall struct {
+	done    chan struct{}
+	res     any // Result[T] of the flight's type
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: make(map[flightKey]*flightCall)}
+}
+
+// doFlight runs fn once for all concurrent callers of the same key and type in
+// g and returns the shared result to each of them.
+//
+// fn runs on its own goroutine with a context detached from any single caller,
+// so one caller's cancellation does not fail the others. Detaching also drops
+// the caller's deadline, so the shared context gets its own: timeout, the
+// handler's background refresh timeout. A caller whose ctx is done stops
+// waiting and gets ctx.Err(); when the last waiter leaves, the shared context
+// is cancelled and the flight is forgotten so later callers start afresh.
+//
+// Parameters:
+//   - ctx: The caller's context; bounds only how long this caller waits.
+//   - g: The flight group, normally the Core's.
+//   - key: Coalescing key (the full cache key).
+//   - timeout: Deadline of the shared context; <= 0 leaves it without one.
+//   - fn: The work to share.
+//
+// Returns:
+//   - Result[T]: The shared result, or a zero value if the caller gave up.
+//   - error: The shared error, or ctx.Err() if the caller gave up.
+func doFlight[T any](
+	ctx context.Context,
+	g *flightGroup,
+	key string,
+	timeout time.Duration,
+	fn func(ctx context.Context) (Result[T], error),
+) (Result[T], error) {
+	fk := flightKey{key: key, typ: reflect.TypeFor[T]()}
+	g.mu.Lock()
+	c, ok := g.calls[fk]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		if timeout > 0 {
+			flightCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		}
+		c = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[fk] = c
+		go func() {
+			c.res, c.err = fn(flightCtx)
+			g.forget(fk, c)
+			cancel()
+			close(c.done)
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.res.(Result[T]), c.err //nolint:forcetypeassert // Flights are keyed by T
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// Nobody is left to receive the result: stop the shared work and make
+			// sure later callers do not join a cancelled flight.
+			if g.calls[fk] == c {
+				delete(g.calls, fk)
+			}
+			c.cancel()
+		}
+		g.mu.Unlock()
+		var zero T
+		return Result[T]{Value: zero}, ctx.Err()
+	}
+}
+
+// forget removes c from the group if it is still the registered flight for fk.
+func (g *flightGroup) forget(fk flightKey, c *flightCall) {
+	g.mu.Lock()
+	if g.calls[fk] == c {
+		delete(g.calls, fk)
+	}
+	g.mu.Unlock()
+}
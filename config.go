@@ -1,13 +1,11 @@
 package cache
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"time"
-
-	"github.com/joho/godotenv"
-	"github.com/redis/go-redis/v9"
 )
 
 // Constants for fallback configuration values.
@@ -26,11 +24,21 @@ const (
 	refreshAheadThresholdFallback = 0.2
 	// defaultProbabilisticBetaFallback is the fallback beta value for probabilistic refresh.
 	defaultProbabilisticBetaFallback = 1.0
+	// hotKeyTopKFallback is the fallback number of hot keys tracked.
+	hotKeyTopKFallback = 10
+	// hotKeySampleRateFallback is the fallback fraction of reads counted by hot key detection.
+	hotKeySampleRateFallback = 0.1
+	// leaseTTLSecondsFallback is the fallback lifetime in seconds of a MissFillLease lease.
+	leaseTTLSecondsFallback = 10
+	// leaseWaitMillisecondsFallback is the fallback time in milliseconds a MissFillLease caller waits for another lease holder.
+	leaseWaitMillisecondsFallback = 100
 )
 
 // handlerConfig holds non-generic configuration fields.
 type handlerConfig struct {
-	rdb                          *redis.Client
+	backend                      Backend
+	readBackend                  Backend       // Optional replica backend for reads; nil means use backend
+	readYourWritesWindow         time.Duration // Read from backend for this long after a local write
 	prefix                       string
 	defaultTTL                   time.Duration
 	bgRefreshTimeout             time.Duration
@@ -44,6 +52,26 @@ type handlerConfig struct {
 	defaultRefreshOlderThanAge   time.Duration // Minimum entry age to trigger HitRefreshOlderThan
 	cooperativeTimeout           time.Duration // Max time to wait for cooperative refresh
 	missDeduplicationWindow      time.Duration // If > 0, suppress generation if this process wrote the key within this window
+	ttlJitter                    float64       // Scale every TTL by a factor in [1-ttlJitter, 1+ttlJitter]
+	ttlJitterMin, ttlJitterMax   time.Duration // Add an offset in [ttlJitterMin, ttlJitterMax] to every TTL
+	deterministicJitter          bool          // Derive jitter from the key instead of randomly
+	slidingExpiration            bool          // Hits reset the TTL, bounded by maxLifetime
+	maxLifetime                  time.Duration
+	hotKeyWindow                 time.Duration // Sliding window for hot key counts; 0 disables detection
+	hotKeyTopK                   int
+	hotKeySampleRate             float64
+	hotKeyThreshold              float64       // Minimum estimated reads per window for promotion
+	hotKeyLocalTTL               time.Duration // Lifetime of in-process copies; 0 disables promotion
+	versionedWrites              bool          // Stamp entries with a version and never overwrite newer ones
+	leaseTTL                     time.Duration // Lifetime of a MissFillLease lease
+	leaseWait                    time.Duration // Max wait for another caller's lease before ErrLeaseHeld
+	leaseStale                   bool          // Keep and serve stale copies of MissFillLease fills
+	decodeFailurePolicy          DecodeFailurePolicy
+	schemaVersion                int               // Embedded in the full key when > 0
+	migrations                   map[int]Migration // Upgrades from older schema versions, keyed by source version
+	observers                    []Observer
+	clock                        Clock
+	synchronousBackground        bool // Run background work inline (tests)
 }
 
 // parseEnvDuration parses an environment variable as a float64 and converts it to a time.Duration with the given unit.
@@ -85,99 +113,300 @@ func parseEnvFloat(envKey string, fallback float64, allowZero bool) (float64, er
 	return value, nil
 }
 
-// loadHandlerConfig loads cache configuration from environment variables with fallback defaults.
-// It returns a configured handlerConfig or an error if the .env file or environment variables cannot be processed.
+// Config is the explicit, serialisable part of a handler's configuration.
+// Start from DefaultConfig or FromEnv, adjust fields, then pass it to
+// NewWithConfig or WithConfig. Runtime wiring (backend, observers, clock,
+// schema migrations) stays in Option functions.
+type Config struct {
+	Prefix                   string
+	DefaultTTL               time.Duration
+	BackgroundRefreshTimeout time.Duration
+	RefreshCooldown          time.Duration // Min gap between background refreshes for the same key after HIT
+	MissFillPolicy           MissFillPolicy
+	HitRefreshPolicy         HitRefreshPolicy
+	ErrorPolicy              ErrorPolicy
+	DecodeFailurePolicy      DecodeFailurePolicy
+	StaleDataTTL             time.Duration // How long to keep stale data for MissFillStaleOrSync
+	RefreshAheadThreshold    float64       // Fraction of TTL remaining that triggers HitRefreshAhead (0..1)
+	ProbabilisticBeta        float64       // Beta for HitRefreshProbabilistic (> 0)
+	RefreshOlderThanAge      time.Duration // Entry age that triggers HitRefreshOlderThan
+	CooperativeTimeout       time.Duration // Max wait for MissFillCooperative
+	MissDeduplicationWindow  time.Duration
+	TTLJitter                float64       // Fractional TTL spread (0.1 = ±10%); 0 disables
+	TTLJitterMin             time.Duration // Lower bound of an absolute TTL offset (may be negative)
+	TTLJitterMax             time.Duration // Upper bound of an absolute TTL offset
+	DeterministicJitter      bool          // Same key, same jitter
+	SlidingExpiration        bool          // Hits reset the entry TTL (see WithSlidingExpiration)
+	MaxLifetime              time.Duration // Absolute lifetime cap for sliding entries
+	HotKeyWindow             time.Duration // Sliding window for hot key detection; 0 disables it
+	HotKeyTopK               int           // Number of hottest keys tracked
+	HotKeySampleRate         float64       // Fraction of reads counted, within (0, 1]
+	HotKeyThreshold          float64       // Minimum estimated reads per window for promotion
+	HotKeyLocalTTL           time.Duration // Lifetime of in-process copies of hot keys; 0 disables promotion
+	VersionedWrites          bool          // Never overwrite an entry with an older one (see WithVersionedWrites)
+	LeaseTTL                 time.Duration // Lifetime of a MissFillLease lease
+	LeaseWait                time.Duration // Max wait for another caller's lease before ErrLeaseHeld
+	LeaseStale               bool          // Keep and serve stale copies of lease fills (see WithLeaseStale)
+}
+
+// DefaultConfig returns the built-in defaults. It does not read the
+// environment.
+func DefaultConfig() Config {
+	return Config{
+		DefaultTTL:               defaultTTLMinutesFallback * time.Minute,
+		BackgroundRefreshTimeout: bgRefreshTimeoutSecondsFallback * time.Second,
+		RefreshCooldown:          refreshCooldownSecondsFallback * time.Second,
+		StaleDataTTL:             staleDataTTLHoursFallback * time.Hour,
+		RefreshAheadThreshold:    refreshAheadThresholdFallback,
+		ProbabilisticBeta:        defaultProbabilisticBetaFallback,
+		CooperativeTimeout:       cooperativeTimeoutSecondsFallback * time.Second,
+		HotKeyTopK:               hotKeyTopKFallback,
+		HotKeySampleRate:         hotKeySampleRateFallback,
+		LeaseTTL:                 leaseTTLSecondsFallback * time.Second,
+		LeaseWait:                leaseWaitMillisecondsFallback * time.Millisecond,
+	}
+}
+
+// FromEnv returns DefaultConfig overridden by environment variables named
+// <prefix>_DEFAULT_TTL_MINUTES, <prefix>_BG_REFRESH_TIMEOUT_SECONDS,
+// <prefix>_STALE_DATA_TTL_HOURS, <prefix>_BG_REFRESH_COOLDOWN_SECONDS,
+// <prefix>_COOPERATIVE_TIMEOUT_SECONDS, <prefix>_REFRESH_AHEAD_THRESHOLD and
+// <prefix>_DEFAULT_PROBABILISTIC_BETA. An empty prefix means "CACHE".
+//
+// FromEnv only reads the process environment; load any .env file beforehand.
 //
 // Parameters:
-//   - rdb: The Redis client to use for cache operations.
+//   - prefix: Environment variable name prefix, without the trailing underscore.
 //
 // Returns:
-//   - *handlerConfig: The populated configuration.
-//   - error: Any error from loading the .env file or parsing environment variables.
-func loadHandlerConfig(rdb *redis.Client) (*handlerConfig, error) {
-	// Load .env file
-	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("failed to load .env file: %w", err)
-	}
-
-	// Parse environment variables with defaults
-	defaultTTL, err := parseEnvDuration(
-		"CACHE_DEFAULT_TTL_MINUTES",
-		time.Minute,
-		defaultTTLMinutesFallback,
-		false,
-	)
-	if err != nil {
-		return nil, err
-	}
-	bgRefreshTimeout, err := parseEnvDuration(
-		"CACHE_BG_REFRESH_TIMEOUT_SECONDS",
-		time.Second,
-		bgRefreshTimeoutSecondsFallback,
-		false,
-	)
-	if err != nil {
-		return nil, err
-	}
-	staleDataTTL, err := parseEnvDuration(
-		"CACHE_STALE_DATA_TTL_HOURS",
-		time.Hour,
-		staleDataTTLHoursFallback,
-		false,
-	)
-	if err != nil {
-		return nil, err
+//   - Config: The populated configuration.
+//   - error: Any error from parsing an environment variable.
+func FromEnv(prefix string) (Config, error) {
+	if prefix == "" {
+		prefix = "CACHE"
 	}
+	cfg := DefaultConfig()
 
-	refreshCooldown, err := parseEnvDuration(
-		"CACHE_BG_REFRESH_COOLDOWN_SECONDS",
-		time.Second,
-		refreshCooldownSecondsFallback,
-		true,
-	)
-	if err != nil {
-		return nil, err
+	durations := []struct {
+		name      string
+		unit      time.Duration
+		fallback  float64
+		allowZero bool
+		dst       *time.Duration
+	}{
+		{"_DEFAULT_TTL_MINUTES", time.Minute, defaultTTLMinutesFallback, false, &cfg.DefaultTTL},
+		{"_BG_REFRESH_TIMEOUT_SECONDS", time.Second, bgRefreshTimeoutSecondsFallback, false, &cfg.BackgroundRefreshTimeout},
+		{"_STALE_DATA_TTL_HOURS", time.Hour, staleDataTTLHoursFallback, false, &cfg.StaleDataTTL},
+		{"_BG_REFRESH_COOLDOWN_SECONDS", time.Second, refreshCooldownSecondsFallback, true, &cfg.RefreshCooldown},
+		{"_COOPERATIVE_TIMEOUT_SECONDS", time.Second, cooperativeTimeoutSecondsFallback, false, &cfg.CooperativeTimeout},
+	}
+	for _, d := range durations {
+		v, err := parseEnvDuration(prefix+d.name, d.unit, d.fallback, d.allowZero)
+		if err != nil {
+			return Config{}, err
+		}
+		*d.dst = v
 	}
 
-	cooperativeTimeout, err := parseEnvDuration(
-		"CACHE_COOPERATIVE_TIMEOUT_SECONDS",
-		time.Second,
-		cooperativeTimeoutSecondsFallback,
-		false,
-	)
-	if err != nil {
-		return nil, err
+	floats := []struct {
+		name     string
+		fallback float64
+		dst      *float64
+	}{
+		{"_REFRESH_AHEAD_THRESHOLD", refreshAheadThresholdFallback, &cfg.RefreshAheadThreshold},
+		{"_DEFAULT_PROBABILISTIC_BETA", defaultProbabilisticBetaFallback, &cfg.ProbabilisticBeta},
+	}
+	for _, f := range floats {
+		v, err := parseEnvFloat(prefix+f.name, f.fallback, false)
+		if err != nil {
+			return Config{}, err
+		}
+		*f.dst = v
 	}
+	return cfg, nil
+}
 
-	refreshAheadThreshold, err := parseEnvFloat(
-		"CACHE_REFRESH_AHEAD_THRESHOLD",
-		refreshAheadThresholdFallback,
-		false,
-	)
-	if err != nil {
-		return nil, err
+// Validate reports every contradictory or out-of-range setting in c. The
+// returned error matches ErrInvalidConfig with errors.Is.
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidConfig}, args...)...))
 	}
 
-	defaultProbabilisticBeta, err := parseEnvFloat(
-		"CACHE_DEFAULT_PROBABILISTIC_BETA",
-		defaultProbabilisticBetaFallback,
-		false,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	config := handlerConfig{
-		rdb:                          rdb,
-		prefix:                       "", // Could also be an env var if needed
-		defaultTTL:                   defaultTTL,
-		bgRefreshTimeout:             bgRefreshTimeout,
-		refreshCooldown:              refreshCooldown,
-		defaultMissFillPolicy:        MissFillDefault,
-		staleDataTTL:                 staleDataTTL,
-		defaultRefreshAheadThreshold: refreshAheadThreshold,
-		defaultProbabilisticBeta:     defaultProbabilisticBeta,
-		cooperativeTimeout:           cooperativeTimeout,
-	}
-	return &config, nil
+	if c.DefaultTTL <= 0 {
+		invalid("DefaultTTL must be > 0, got %v", c.DefaultTTL)
+	}
+	if c.BackgroundRefreshTimeout <= 0 {
+		invalid("BackgroundRefreshTimeout must be > 0, got %v", c.BackgroundRefreshTimeout)
+	}
+	if c.RefreshCooldown < 0 {
+		invalid("RefreshCooldown must be >= 0, got %v", c.RefreshCooldown)
+	}
+	if c.DefaultTTL > 0 && c.RefreshCooldown > c.DefaultTTL {
+		invalid("RefreshCooldown %v is longer than DefaultTTL %v", c.RefreshCooldown, c.DefaultTTL)
+	}
+	if c.MissFillPolicy < MissFillDefault || c.MissFillPolicy > MissFillLease {
+		invalid("unknown MissFillPolicy %d", c.MissFillPolicy)
+	}
+	if c.HitRefreshPolicy < HitRefreshDefault || c.HitRefreshPolicy > HitRefreshNone {
+		invalid("unknown HitRefreshPolicy %d", c.HitRefreshPolicy)
+	}
+	if c.ErrorPolicy < ErrorPolicySurface || c.ErrorPolicy > ErrorPolicyZeroValue {
+		invalid("unknown ErrorPolicy %d", c.ErrorPolicy)
+	}
+	if c.DecodeFailurePolicy < DecodeFailureSurface || c.DecodeFailurePolicy > DecodeFailureDelete {
+		invalid("unknown DecodeFailurePolicy %d", c.DecodeFailurePolicy)
+	}
+	if c.StaleDataTTL < 0 {
+		invalid("StaleDataTTL must be >= 0, got %v", c.StaleDataTTL)
+	}
+	if c.MissFillPolicy == MissFillStaleOrSync && c.StaleDataTTL <= 0 {
+		invalid("MissFillStaleOrSync requires StaleDataTTL > 0")
+	}
+	if c.RefreshAheadThreshold < 0 || c.RefreshAheadThreshold > 1 {
+		invalid("RefreshAheadThreshold must be within 0..1, got %v", c.RefreshAheadThreshold)
+	}
+	if c.ProbabilisticBeta <= 0 {
+		invalid("ProbabilisticBeta must be > 0, got %v", c.ProbabilisticBeta)
+	}
+	if c.RefreshOlderThanAge < 0 {
+		invalid("RefreshOlderThanAge must be >= 0, got %v", c.RefreshOlderThanAge)
+	}
+	if c.HitRefreshPolicy == HitRefreshOlderThan && c.RefreshOlderThanAge <= 0 {
+		invalid("HitRefreshOlderThan requires RefreshOlderThanAge > 0")
+	}
+	if c.CooperativeTimeout < 0 {
+		invalid("CooperativeTimeout must be >= 0, got %v", c.CooperativeTimeout)
+	}
+	if c.MissFillPolicy == MissFillCooperative && c.CooperativeTimeout <= 0 {
+		invalid("MissFillCooperative requires CooperativeTimeout > 0")
+	}
+	if c.MissDeduplicationWindow < 0 {
+		invalid("MissDeduplicationWindow must be >= 0, got %v", c.MissDeduplicationWindow)
+	}
+	if c.TTLJitter < 0 || c.TTLJitter >= 1 {
+		invalid("TTLJitter must be within [0, 1), got %v", c.TTLJitter)
+	}
+	if c.TTLJitterMin > c.TTLJitterMax {
+		invalid("TTLJitterMin %v is greater than TTLJitterMax %v", c.TTLJitterMin, c.TTLJitterMax)
+	}
+	if c.MaxLifetime < 0 {
+		invalid("MaxLifetime must be >= 0, got %v", c.MaxLifetime)
+	}
+	if c.SlidingExpiration && c.MaxLifetime <= 0 {
+		invalid("SlidingExpiration requires MaxLifetime > 0")
+	}
+	if c.HotKeyWindow < 0 {
+		invalid("HotKeyWindow must be >= 0, got %v", c.HotKeyWindow)
+	}
+	if c.HotKeyWindow > 0 && c.HotKeyTopK <= 0 {
+		invalid("HotKeyTopK must be > 0, got %d", c.HotKeyTopK)
+	}
+	if c.HotKeyWindow > 0 && (c.HotKeySampleRate <= 0 || c.HotKeySampleRate > 1) {
+		invalid("HotKeySampleRate must be within (0, 1], got %v", c.HotKeySampleRate)
+	}
+	if c.HotKeyThreshold < 0 {
+		invalid("HotKeyThreshold must be >= 0, got %v", c.HotKeyThreshold)
+	}
+	if c.HotKeyLocalTTL < 0 {
+		invalid("HotKeyLocalTTL must be >= 0, got %v", c.HotKeyLocalTTL)
+	}
+	if c.HotKeyLocalTTL > 0 && c.HotKeyWindow <= 0 {
+		invalid("HotKeyLocalTTL requires HotKeyWindow > 0")
+	}
+	if c.LeaseTTL <= 0 { // Per-call MissFillLease may need it under any default policy
+		invalid("LeaseTTL must be > 0, got %v", c.LeaseTTL)
+	}
+	if c.LeaseWait < 0 {
+		invalid("LeaseWait must be >= 0, got %v", c.LeaseWait)
+	}
+	if c.LeaseStale && c.StaleDataTTL <= 0 {
+		invalid("LeaseStale requires StaleDataTTL > 0")
+	}
+	if c.DefaultTTL > 0 && c.DefaultTTL+c.TTLJitterMin <= 0 {
+		invalid("TTLJitterMin %v would make DefaultTTL %v non-positive", c.TTLJitterMin, c.DefaultTTL)
+	}
+	return errors.Join(errs...)
+}
+
+// WithConfig replaces every Config field of the handler configuration with cfg.
+// Options listed after it still override individual fields.
+func WithConfig(cfg Config) Option {
+	return func(c *handlerConfig) { c.apply(cfg) }
+}
+
+// newHandlerConfig returns a handlerConfig populated from cfg.
+func newHandlerConfig(cfg Config) *handlerConfig {
+	c := &handlerConfig{clock: systemClock{}}
+	c.apply(cfg)
+	return c
+}
+
+// apply copies every Config field into c.
+func (c *handlerConfig) apply(cfg Config) {
+	c.prefix = cfg.Prefix
+	c.defaultTTL = cfg.DefaultTTL
+	c.bgRefreshTimeout = cfg.BackgroundRefreshTimeout
+	c.refreshCooldown = cfg.RefreshCooldown
+	c.defaultMissFillPolicy = cfg.MissFillPolicy
+	c.defaultHitRefreshPolicy = cfg.HitRefreshPolicy
+	c.defaultErrorPolicy = cfg.ErrorPolicy
+	c.decodeFailurePolicy = cfg.DecodeFailurePolicy
+	c.staleDataTTL = cfg.StaleDataTTL
+	c.defaultRefreshAheadThreshold = cfg.RefreshAheadThreshold
+	c.defaultProbabilisticBeta = cfg.ProbabilisticBeta
+	c.defaultRefreshOlderThanAge = cfg.RefreshOlderThanAge
+	c.cooperativeTimeout = cfg.CooperativeTimeout
+	c.missDeduplicationWindow = cfg.MissDeduplicationWindow
+	c.ttlJitter = cfg.TTLJitter
+	c.ttlJitterMin = cfg.TTLJitterMin
+	c.ttlJitterMax = cfg.TTLJitterMax
+	c.deterministicJitter = cfg.DeterministicJitter
+	c.slidingExpiration = cfg.SlidingExpiration
+	c.maxLifetime = cfg.MaxLifetime
+	c.hotKeyWindow = cfg.HotKeyWindow
+	c.hotKeyTopK = cfg.HotKeyTopK
+	c.hotKeySampleRate = cfg.HotKeySampleRate
+	c.hotKeyThreshold = cfg.HotKeyThreshold
+	c.hotKeyLocalTTL = cfg.HotKeyLocalTTL
+	c.versionedWrites = cfg.VersionedWrites
+	c.leaseTTL = cfg.LeaseTTL
+	c.leaseWait = cfg.LeaseWait
+	c.leaseStale = cfg.LeaseStale
+}
+
+// exported returns the Config fields of c.
+func (c *handlerConfig) exported() Config {
+	return Config{
+		Prefix:                   c.prefix,
+		DefaultTTL:               c.defaultTTL,
+		BackgroundRefreshTimeout: c.bgRefreshTimeout,
+		RefreshCooldown:          c.refreshCooldown,
+		MissFillPolicy:           c.defaultMissFillPolicy,
+		HitRefreshPolicy:         c.defaultHitRefreshPolicy,
+		ErrorPolicy:              c.defaultErrorPolicy,
+		DecodeFailurePolicy:      c.decodeFailurePolicy,
+		StaleDataTTL:             c.staleDataTTL,
+		RefreshAheadThreshold:    c.defaultRefreshAheadThreshold,
+		ProbabilisticBeta:        c.defaultProbabilisticBeta,
+		RefreshOlderThanAge:      c.defaultRefreshOlderThanAge,
+		CooperativeTimeout:       c.cooperativeTimeout,
+		MissDeduplicationWindow:  c.missDeduplicationWindow,
+		TTLJitter:                c.ttlJitter,
+		TTLJitterMin:             c.ttlJitterMin,
+		TTLJitterMax:             c.ttlJitterMax,
+		DeterministicJitter:      c.deterministicJitter,
+		SlidingExpiration:        c.slidingExpiration,
+		MaxLifetime:              c.maxLifetime,
+		HotKeyWindow:             c.hotKeyWindow,
+		HotKeyTopK:               c.hotKeyTopK,
+		HotKeySampleRate:         c.hotKeySampleRate,
+		HotKeyThreshold:          c.hotKeyThreshold,
+		HotKeyLocalTTL:           c.hotKeyLocalTTL,
+		VersionedWrites:          c.versionedWrites,
+		LeaseTTL:                 c.leaseTTL,
+		LeaseWait:                c.leaseWait,
+		LeaseStale:               c.leaseStale,
+	}
 }
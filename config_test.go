@@ -0,0 +1,87 @@
+package cache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+)
+
+// TestConfig tests Config defaults, environment loading and validation.
+func TestConfig(t *testing.T) {
+	t.Run("Defaults are valid", func(t *testing.T) {
+		if err := cache.DefaultConfig().Validate(); err != nil {
+			t.Errorf("Expected DefaultConfig to be valid, got %v", err)
+		}
+	})
+
+	t.Run("FromEnv", func(t *testing.T) {
+		t.Setenv("MYAPP_DEFAULT_TTL_MINUTES", "10")
+		t.Setenv("MYAPP_REFRESH_AHEAD_THRESHOLD", "0.5")
+		t.Setenv("CACHE_DEFAULT_TTL_MINUTES", "99")
+
+		cfg, err := cache.FromEnv("MYAPP")
+		if err != nil {
+			t.Fatalf("FromEnv failed: %v", err)
+		}
+		if cfg.DefaultTTL != 10*time.Minute || cfg.RefreshAheadThreshold != 0.5 {
+			t.Errorf("Expected values from MYAPP_* variables, got %+v", cfg)
+		}
+		if cfg.BackgroundRefreshTimeout != cache.DefaultConfig().BackgroundRefreshTimeout {
+			t.Errorf("Expected unset variables to keep defaults, got %v", cfg.BackgroundRefreshTimeout)
+		}
+
+		t.Setenv("MYAPP_DEFAULT_TTL_MINUTES", "abc")
+		if _, err = cache.FromEnv("MYAPP"); err == nil {
+			t.Error("Expected parse error for invalid variable")
+		}
+	})
+
+	t.Run("Validate rejects contradictions", func(t *testing.T) {
+		tests := map[string]func(*cache.Config){
+			"stale-or-sync without stale TTL": func(c *cache.Config) {
+				c.MissFillPolicy = cache.MissFillStaleOrSync
+				c.StaleDataTTL = 0
+			},
+			"threshold above 1":      func(c *cache.Config) { c.RefreshAheadThreshold = 1.5 },
+			"threshold below 0":      func(c *cache.Config) { c.RefreshAheadThreshold = -0.1 },
+			"cooldown exceeds TTL":   func(c *cache.Config) { c.RefreshCooldown = c.DefaultTTL + time.Second },
+			"non-positive TTL":       func(c *cache.Config) { c.DefaultTTL = 0 },
+			"non-positive beta":      func(c *cache.Config) { c.ProbabilisticBeta = 0 },
+			"unknown miss policy":    func(c *cache.Config) { c.MissFillPolicy = 99 },
+			"older-than without age": func(c *cache.Config) { c.HitRefreshPolicy = cache.HitRefreshOlderThan },
+		}
+		for name, mutate := range tests {
+			cfg := cache.DefaultConfig()
+			mutate(&cfg)
+			if err := cfg.Validate(); !errors.Is(err, cache.ErrInvalidConfig) {
+				t.Errorf("%s: expected ErrInvalidConfig, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Constructors validate options", func(t *testing.T) {
+		rdb := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+		defer rdb.Close()
+
+		if _, err := cache.New[string](rdb, cache.WithRefreshAheadThreshold(2)); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected New to reject an out-of-range threshold, got %v", err)
+		}
+
+		cfg := cache.DefaultConfig()
+		cfg.Prefix = "svc"
+		cfg.MissFillPolicy = cache.MissFillCoalesce
+		h, err := cache.NewWithConfig[string](rdb, cfg)
+		if err != nil || h == nil {
+			t.Fatalf("NewWithConfig failed: %v", err)
+		}
+
+		cfg.RefreshCooldown = time.Hour
+		if _, err = cache.NewWithConfig[string](rdb, cfg); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected NewWithConfig to reject cooldown > TTL, got %v", err)
+		}
+	})
+}
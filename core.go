@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Core is the non-generic state shared by every handler created from it: one
+// backend client, one lock table, one background task pool and one parsed
+// configuration. Create typed views with Typed; each view is cheap and may
+// override the prefix and policies without repeating the setup.
+type Core struct {
+	config           handlerConfig
+	localLocks       *KeyedMutex
+	bg               *bgTracker
+	lastRefreshByKey map[string]time.Time
+	readPrimaryUntil map[string]time.Time // Ends of the read-your-writes windows of local writes
+	nextWriteSweep   time.Time            // When readPrimaryUntil is next swept of ended windows
+	lastRefreshMu    sync.Mutex
+	flights          *flightGroup // Shared MissFillCoalesce and MissFillLease fills
+	hotKeys          *hotKeyTracker
+	reads            *readTracker // Last reads of keys registered with a Scheduler
+	lastVersion      atomic.Int64 // Last entry version issued; see WithVersionedWrites
+}
+
+// NewCore creates a Core backed by the Redis client rdb. opts become the
+// defaults of every view created with Typed.
+func NewCore(rdb *redis.Client, opts ...Option) (*Core, error) {
+	return NewCoreWithBackend(NewRedisBackend(rdb), opts...)
+}
+
+// NewCoreWithBackend creates a Core that stores entries in b.
+func NewCoreWithBackend(b Backend, opts ...Option) (*Core, error) {
+	config := newHandlerConfig(DefaultConfig())
+	config.backend = b
+
+	for _, o := range opts {
+		o(config)
+	}
+	if err := config.exported().Validate(); err != nil {
+		return nil, err
+	}
+	return &Core{
+		config:           *config,
+		localLocks:       NewKeyedMutex(),
+		bg:               &bgTracker{},
+		lastRefreshByKey: make(map[string]time.Time),
+		readPrimaryUntil: make(map[string]time.Time),
+		flights:          newFlightGroup(),
+		hotKeys:          newHotKeyTracker(),
+		reads:            newReadTracker(),
+	}, nil
+}
+
+// Typed returns a Handler[T] view of core. prefix is appended to the core
+// prefix ("app" + "user" stores keys under "app:user:"); an empty prefix keeps
+// the core prefix. opts override the core configuration for this view only.
+//
+// Views share the core's backend, locks, background pool and refresh
+// bookkeeping, so WaitIdle on any view waits for the whole core. An error is
+// returned only when opts leave the view with an invalid configuration.
+func Typed[T any](core *Core, prefix string, opts ...Option) (*Handler[T], error) {
+	config := core.config
+	config.observers = slices.Clip(config.observers)  // Appends in opts must not alias the core's slice
+	config.migrations = maps.Clone(config.migrations) // Nor may WithSchemaMigration write to the core's map
+	switch {
+	case prefix == "":
+	case config.prefix == "":
+		config.prefix = prefix
+	default:
+		config.prefix += ":" + prefix
+	}
+	for _, o := range opts {
+		o(&config)
+	}
+	if len(opts) > 0 {
+		if err := config.exported().Validate(); err != nil {
+			return nil, err
+		}
+	}
+	live := &liveConfig{}
+	live.cur.Store(&config)
+	return &Handler[T]{
+		config: &config,
+		live:   live,
+		core:   core,
+	}, nil
+}
+
+// WaitIdle blocks until every background task started by any view of the
+// core has finished, or ctx is done.
+func (c *Core) WaitIdle(ctx context.Context) error {
+	return c.bg.wait(ctx)
+}
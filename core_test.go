@@ -0,0 +1,99 @@
+package cache_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+// TestTyped tests typed views sharing a single Core.
+func TestTyped(t *testing.T) {
+	ctx := context.Background()
+	b := cache.NewMemoryBackend()
+	core, err := cache.NewCoreWithBackend(b, cache.WithPrefix("app"), cache.WithDefaultTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("NewCoreWithBackend failed: %v", err)
+	}
+
+	users, _ := cache.Typed[user](core, "user")
+	counts, err := cache.Typed[int](core, "count", cache.WithMissFillPolicy(cache.MissFillFailFast))
+	if err != nil {
+		t.Fatalf("Typed failed: %v", err)
+	}
+
+	t.Run("Per-type prefix", func(t *testing.T) {
+		if err = users.Set(ctx, "1", user{Name: "ada"}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if err = counts.Set(ctx, "1", 42); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		for _, k := range []string{"app:user:1", "app:count:1"} {
+			if exists, _ := b.Exists(ctx, k); !exists {
+				t.Errorf("Expected key %q to exist", k)
+			}
+		}
+		if result, err := users.Get(ctx, "1"); err != nil || result.Value.Name != "ada" {
+			t.Errorf("Expected user ada, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("Per-type policy override", func(t *testing.T) {
+		_, err := counts.GetOrRefresh(ctx, "missing", func(_ context.Context) (int, error) { return 1, nil })
+		if !errors.Is(err, cache.ErrCacheMiss) {
+			t.Errorf("Expected fail-fast override on the count view, got %v", err)
+		}
+		result, err := users.GetOrRefresh(ctx, "missing", func(_ context.Context) (user, error) {
+			return user{Name: "bob"}, nil
+		})
+		if err != nil || result.Value.Name != "bob" {
+			t.Errorf("Expected the user view to keep the core sync policy, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("Shared background pool", func(t *testing.T) {
+		async, _ := cache.Typed[string](core, "async", cache.WithMissFillPolicy(cache.MissFillAsync))
+		_, _ = async.GetOrRefresh(ctx, "k", func(_ context.Context) (string, error) { return "v", nil })
+		if err := core.WaitIdle(ctx); err != nil {
+			t.Fatalf("WaitIdle failed: %v", err)
+		}
+		if exists, _ := b.Exists(ctx, "app:async:k"); !exists {
+			t.Error("Expected core WaitIdle to cover the view's background write")
+		}
+	})
+
+	t.Run("Per-view migrations", func(t *testing.T) {
+		migrateTo := func(s string) cache.Migration {
+			return func(json.RawMessage) (json.RawMessage, error) { return json.Marshal(s) }
+		}
+		versioned, _ := cache.NewCoreWithBackend(b, cache.WithPrefix("app"), cache.WithSchemaVersion(2),
+			cache.WithSchemaMigration(1, migrateTo("from-core")))
+		a, _ := cache.Typed[string](versioned, "a", cache.WithSchemaMigration(1, migrateTo("from-a")))
+		bv, _ := cache.Typed[string](versioned, "b")
+		for _, k := range []string{"app:a:v1:k", "app:b:v1:k"} {
+			_ = b.Set(ctx, k, []byte(`"old"`), time.Minute)
+		}
+
+		if r, _ := a.Get(ctx, "k"); r.Value != "from-a" {
+			t.Errorf("Expected the view's own migration, got %q", r.Value)
+		}
+		if r, _ := bv.Get(ctx, "k"); r.Value != "from-core" {
+			t.Errorf("Expected another view's migration not to leak, got %q", r.Value)
+		}
+	})
+
+	t.Run("Invalid override", func(t *testing.T) {
+		_, err := cache.Typed[string](core, "bad", cache.WithRefreshCooldown(time.Hour))
+		if !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for cooldown > TTL, got %v", err)
+		}
+	})
+}
@@ -49,7 +49,7 @@ import (
 // All fields are optional; zero values mean "use library default".
 // Policy fields map 1-to-1 onto the Go iota constants:
 //
-//	MissFillPolicy:    0=Default 1=Sync 2=Async 3=StaleOrSync 4=FailFast 5=Cooperative
+//	MissFillPolicy:    0=Default 1=Sync 2=Async 3=StaleOrSync 4=FailFast 5=Cooperative 6=Coalesce 7=Lease
 //	HitRefreshPolicy:  0=Default 1=Ahead 2=Probabilistic 3=OlderThan 4=None
 //	ErrorPolicy:       0=Surface 1=ZeroValue
 // ---------------------------------------------------------------------------
@@ -123,7 +123,9 @@ func deleteHandle(id int64) {
 // handle, or -1 on error.
 //
 // configJSON is a JSON object with all handler options. Only redisAddr is
-// required. Example:
+// required. Options left out (or zero) take their value from the CACHE_*
+// environment variables read by cache.FromEnv, then from the library
+// defaults. Example:
 //
 //	{
 //	  "prefix":              "myapp",
@@ -158,10 +160,10 @@ func CashCov_NewHandler(redisAddr *C.char, configJSON *C.char) C.int64_t {
 	})
 
 	// Validate policy enum ranges before passing raw integers to Go iota constants.
-	// MissFillPolicy:   0=Default 1=Sync 2=Async 3=StaleOrSync 4=FailFast 5=Cooperative
+	// MissFillPolicy:   0=Default 1=Sync 2=Async 3=StaleOrSync 4=FailFast 5=Cooperative 6=Coalesce 7=Lease
 	// HitRefreshPolicy: 0=Default 1=Ahead 2=Probabilistic 3=OlderThan 4=None
 	// ErrorPolicy:      0=Surface 1=ZeroValue
-	if cfg.MissFillPolicy < 0 || cfg.MissFillPolicy > 5 {
+	if cfg.MissFillPolicy < 0 || cfg.MissFillPolicy > 7 {
 		return -1
 	}
 	if cfg.HitRefreshPolicy < 0 || cfg.HitRefreshPolicy > 4 {
@@ -171,18 +173,20 @@ func CashCov_NewHandler(redisAddr *C.char, configJSON *C.char) C.int64_t {
 		return -1
 	}
 
-	ttl := cfg.TTLSecs
-	if ttl <= 0 {
-		ttl = 300 // 5-minute fallback
+	base, err := cache.FromEnv("")
+	if err != nil {
+		return -1
 	}
 
 	opts := []cache.Option{
 		cache.WithPrefix(cfg.Prefix),
-		cache.WithDefaultTTL(time.Duration(ttl) * time.Second),
 		cache.WithMissFillPolicy(cache.MissFillPolicy(cfg.MissFillPolicy)),
 		cache.WithDefaultHitRefreshPolicy(cache.HitRefreshPolicy(cfg.HitRefreshPolicy)),
 		cache.WithDefaultErrorPolicy(cache.ErrorPolicy(cfg.ErrorPolicy)),
 	}
+	if cfg.TTLSecs > 0 {
+		opts = append(opts, cache.WithDefaultTTL(time.Duration(cfg.TTLSecs)*time.Second))
+	}
 	if cfg.StaleTTLSecs > 0 {
 		opts = append(opts, cache.WithStaleDataTTL(time.Duration(cfg.StaleTTLSecs)*time.Second))
 	}
@@ -205,7 +209,7 @@ func CashCov_NewHandler(redisAddr *C.char, configJSON *C.char) C.int64_t {
 		opts = append(opts, cache.WithProbabilisticBeta(cfg.ProbabilisticBeta))
 	}
 
-	h, err := cache.New[string](rdb, opts...)
+	h, err := cache.NewWithConfig[string](rdb, base, opts...)
 	if err != nil {
 		return -1
 	}
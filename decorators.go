@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Logging wraps c so that every call is logged to logger: successful calls at
+// debug level, failures (other than ErrNotFound) at warn level. A nil logger
+// uses slog.Default().
+func Logging[T any](c Cache[T], logger *slog.Logger) Cache[T] {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &loggingCache[T]{next: c, logger: logger}
+}
+
+type loggingCache[T any] struct {
+	next   Cache[T]
+	logger *slog.Logger
+}
+
+func (l *loggingCache[T]) Get(ctx context.Context, key string) (Result[T], error) {
+	start := time.Now()
+	res, err := l.next.Get(ctx, key)
+	l.log(ctx, "get", key, start, err, slog.Bool("from_cache", res.FromCache))
+	return res, err
+}
+
+func (l *loggingCache[T]) Set(ctx context.Context, key string, value T, opts ...CallOption) error {
+	start := time.Now()
+	err := l.next.Set(ctx, key, value, opts...)
+	l.log(ctx, "set", key, start, err)
+	return err
+}
+
+func (l *loggingCache[T]) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := l.next.Delete(ctx, key)
+	l.log(ctx, "delete", key, start, err)
+	return err
+}
+
+func (l *loggingCache[T]) GetOrRefresh(
+	ctx context.Context,
+	key string,
+	gen Generator[T],
+	opts ...CallOption,
+) (Result[T], error) {
+	start := time.Now()
+	res, err := l.next.GetOrRefresh(ctx, key, gen, opts...)
+	l.log(ctx, "get_or_refresh", key, start, err, slog.Bool("from_cache", res.FromCache))
+	return res, err
+}
+
+func (l *loggingCache[T]) unwrap() Cache[T] { return l.next }
+
+func (l *loggingCache[T]) log(ctx context.Context, op, key string, start time.Time, err error, attrs ...slog.Attr) {
+	attrs = append(attrs,
+		slog.String("op", op),
+		slog.String("key", key),
+		slog.Duration("duration", time.Since(start)),
+	)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		attrs = append(attrs, slog.Any("error", err))
+		l.logger.LogAttrs(ctx, slog.LevelWarn, "cache call failed", attrs...)
+		return
+	}
+	l.logger.LogAttrs(ctx, slog.LevelDebug, "cache call", attrs...)
+}
+
+// MetricsRecorder receives one observation per call made through Metrics.
+// Adapt it to Prometheus, OpenTelemetry or any other metrics library.
+type MetricsRecorder interface {
+	// ObserveCall reports the operation name ("get", "set", "delete",
+	// "get_or_refresh"), whether the value was served from the cache, the call
+	// duration and its error (nil on success).
+	ObserveCall(op string, fromCache bool, d time.Duration, err error)
+}
+
+// Metrics wraps c so that every call is reported to m.
+func Metrics[T any](c Cache[T], m MetricsRecorder) Cache[T] {
+	return &metricsCache[T]{next: c, m: m}
+}
+
+type metricsCache[T any] struct {
+	next Cache[T]
+	m    MetricsRecorder
+}
+
+func (mc *metricsCache[T]) Get(ctx context.Context, key string) (Result[T], error) {
+	start := time.Now()
+	res, err := mc.next.Get(ctx, key)
+	mc.m.ObserveCall("get", res.FromCache, time.Since(start), err)
+	return res, err
+}
+
+func (mc *metricsCache[T]) Set(ctx context.Context, key string, value T, opts ...CallOption) error {
+	start := time.Now()
+	err := mc.next.Set(ctx, key, value, opts...)
+	mc.m.ObserveCall("set", false, time.Since(start), err)
+	return err
+}
+
+func (mc *metricsCache[T]) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := mc.next.Delete(ctx, key)
+	mc.m.ObserveCall("delete", false, time.Since(start), err)
+	return err
+}
+
+func (mc *metricsCache[T]) GetOrRefresh(
+	ctx context.Context,
+	key string,
+	gen Generator[T],
+	opts ...CallOption,
+) (Result[T], error) {
+	start := time.Now()
+	res, err := mc.next.GetOrRefresh(ctx, key, gen, opts...)
+	mc.m.ObserveCall("get_or_refresh", res.FromCache, time.Since(start), err)
+	return res, err
+}
+
+func (mc *metricsCache[T]) unwrap() Cache[T] { return mc.next }
+
+// ReadOnly wraps c so that callers cannot write through it: Set and Delete
+// return ErrReadOnly, and GetOrRefresh serves hits from c but answers misses
+// by calling the generator without storing the result. Reads still go through
+// c.Get, so a handler with DecodeFailureDelete underneath may delete an entry
+// it cannot decode.
+func ReadOnly[T any](c Cache[T]) Cache[T] {
+	return readOnlyCache[T]{next: c}
+}
+
+type readOnlyCache[T any] struct {
+	next Cache[T]
+}
+
+func (r readOnlyCache[T]) Get(ctx context.Context, key string) (Result[T], error) {
+	return r.next.Get(ctx, key)
+}
+
+func (r readOnlyCache[T]) Set(context.Context, string, T, ...CallOption) error {
+	return ErrReadOnly
+}
+
+func (r readOnlyCache[T]) Delete(context.Context, string) error {
+	return ErrReadOnly
+}
+
+func (r readOnlyCache[T]) GetOrRefresh(
+	ctx context.Context,
+	key string,
+	gen Generator[T],
+	_ ...CallOption,
+) (Result[T], error) {
+	res, err := r.next.Get(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		return res, err
+	}
+	if h := handlerOf(r.next); h != nil {
+		return generateUncached(ctx, h.fullKey(key), gen, h.now)
+	}
+	return generateUncached(ctx, key, gen, time.Now)
+}
+
+func (r readOnlyCache[T]) unwrap() Cache[T] { return r.next }
+
+// NoOp returns a Cache that stores nothing: Get always misses with
+// ErrNotFound, Set and Delete succeed without effect, and GetOrRefresh always
+// calls the generator. Use it to disable caching without changing call sites.
+func NoOp[T any]() Cache[T] {
+	return noOpCache[T]{}
+}
+
+type noOpCache[T any] struct{}
+
+func (noOpCache[T]) Get(context.Context, string) (Result[T], error) {
+	return Result[T]{}, ErrNotFound
+}
+
+func (noOpCache[T]) Set(context.Context, string, T, ...CallOption) error { return nil }
+
+func (noOpCache[T]) Delete(context.Context, string) error { return nil }
+
+func (noOpCache[T]) GetOrRefresh(ctx context.Context, key string, gen Generator[T], _ ...CallOption) (Result[T], error) {
+	return generateUncached(ctx, key, gen, time.Now)
+}
+
+// generateUncached calls gen and returns its value without storing it, stamped
+// with now once gen has returned. fullKey is reported in a GeneratorError.
+func generateUncached[T any](ctx context.Context, fullKey string, gen Generator[T], now func() time.Time) (Result[T], error) {
+	v, err := gen(ctx)
+	if err != nil {
+		return Result[T]{}, &GeneratorError{Key: fullKey, Err: err}
+	}
+	return Result[T]{Value: v, FromCache: false, CachedAt: now()}, nil
+}
+
+// handlerView is implemented by *Handler and by types that embed it.
+type handlerView interface {
+	now() time.Time
+	fullKey(key string) string
+}
+
+// handlerOf returns the Handler underneath c, looking through decorators, or
+// nil when c is not backed by a Handler.
+func handlerOf[T any](c Cache[T]) handlerView {
+	for {
+		switch v := c.(type) {
+		case handlerView:
+			return v
+		case interface{ unwrap() Cache[T] }:
+			c = v.unwrap()
+		default:
+			return nil
+		}
+	}
+}
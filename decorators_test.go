@@ -0,0 +1,158 @@
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+type recordedCall struct {
+	op        string
+	fromCache bool
+	err       error
+}
+
+type callRecorder struct {
+	calls []recordedCall
+}
+
+func (r *callRecorder) ObserveCall(op string, fromCache bool, _ time.Duration, err error) {
+	r.calls = append(r.calls, recordedCall{op: op, fromCache: fromCache, err: err})
+}
+
+// TestDecorators tests the Cache[T] decorators on top of a memory-backed handler.
+func TestDecorators(t *testing.T) {
+	ctx := context.Background()
+	gen := func(_ context.Context) (string, error) { return "generated", nil }
+
+	newHandler := func(t *testing.T) *cache.Handler[string] {
+		t.Helper()
+		h, err := cache.NewWithBackend[string](cache.NewMemoryBackend(), cache.WithPrefix("test"))
+		if err != nil {
+			t.Fatalf("NewWithBackend failed: %v", err)
+		}
+		return h
+	}
+
+	t.Run("Handler Delete", func(t *testing.T) {
+		h := newHandler(t)
+		_ = h.Set(ctx, "key", "value")
+		if err := h.Delete(ctx, "key"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if _, err := h.Get(ctx, "key"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+		}
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		rec := &callRecorder{}
+		c := cache.Metrics[string](newHandler(t), rec)
+
+		_, _ = c.Get(ctx, "key")
+		_, _ = c.GetOrRefresh(ctx, "key", gen, cache.WithoutBackgroundRefresh())
+		_, _ = c.GetOrRefresh(ctx, "key", gen, cache.WithoutBackgroundRefresh())
+		_ = c.Delete(ctx, "key")
+
+		want := []recordedCall{
+			{op: "get", err: cache.ErrNotFound},
+			{op: "get_or_refresh"},
+			{op: "get_or_refresh", fromCache: true},
+			{op: "delete"},
+		}
+		if len(rec.calls) != len(want) {
+			t.Fatalf("Expected %d calls, got %+v", len(want), rec.calls)
+		}
+		for i, w := range want {
+			got := rec.calls[i]
+			if got.op != w.op || got.fromCache != w.fromCache || !errors.Is(got.err, w.err) {
+				t.Errorf("call %d: expected %+v, got %+v", i, w, got)
+			}
+		}
+	})
+
+	t.Run("Logging", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		c := cache.Logging[string](cache.ReadOnly[string](newHandler(t)), logger)
+
+		if err := c.Set(ctx, "key", "value"); !errors.Is(err, cache.ErrReadOnly) {
+			t.Fatalf("Expected ErrReadOnly, got %v", err)
+		}
+		_, _ = c.Get(ctx, "key")
+
+		out := buf.String()
+		if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "op=set") {
+			t.Errorf("Expected failed set to be logged at warn level, got %q", out)
+		}
+		if !strings.Contains(out, "level=DEBUG") || !strings.Contains(out, "op=get") {
+			t.Errorf("Expected miss to be logged at debug level, got %q", out)
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		h := newHandler(t)
+		c := cache.ReadOnly[string](h)
+
+		if err := c.Delete(ctx, "key"); !errors.Is(err, cache.ErrReadOnly) {
+			t.Errorf("Expected ErrReadOnly from Delete, got %v", err)
+		}
+		result, err := c.GetOrRefresh(ctx, "key", gen)
+		if err != nil || result.FromCache || result.Value != "generated" {
+			t.Fatalf("Expected generated value, got %+v, %v", result, err)
+		}
+		if _, err = h.Get(ctx, "key"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected ReadOnly miss not to be stored, got %v", err)
+		}
+
+		_ = h.Set(ctx, "key", "cached")
+		if result, _ = c.GetOrRefresh(ctx, "key", gen); !result.FromCache || result.Value != "cached" {
+			t.Errorf("Expected cached value, got %+v", result)
+		}
+	})
+
+	t.Run("ReadOnly uses the handler clock", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string]()
+		c := cache.ReadOnly[string](cache.Logging[string](h, slog.New(slog.DiscardHandler)))
+		result, err := c.GetOrRefresh(ctx, "key", func(_ context.Context) (string, error) {
+			h.Clock.Advance(time.Hour) // A slow generator
+			return "generated", nil
+		})
+		if err != nil || !result.CachedAt.Equal(h.Clock.Now()) {
+			t.Errorf("Expected CachedAt %v, after the generator, got %v, %v", h.Clock.Now(), result.CachedAt, err)
+		}
+	})
+
+	t.Run("ReadOnly reports the full key", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](cache.WithPrefix("app"))
+		c := cache.ReadOnly[string](h)
+		_, err := c.GetOrRefresh(ctx, "key", func(_ context.Context) (string, error) { return "", errors.New("boom") })
+		var genErr *cache.GeneratorError
+		if !errors.As(err, &genErr) || genErr.Key != "app:key" {
+			t.Errorf("Expected a GeneratorError for app:key, got %v", err)
+		}
+	})
+
+	t.Run("NoOp", func(t *testing.T) {
+		c := cache.NoOp[string]()
+		if err := c.Set(ctx, "key", "value"); err != nil {
+			t.Errorf("Expected Set to succeed, got %v", err)
+		}
+		if _, err := c.Get(ctx, "key"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		genErr := errors.New("boom")
+		_, err := c.GetOrRefresh(ctx, "key", func(_ context.Context) (string, error) { return "", genErr })
+		var ge *cache.GeneratorError
+		if !errors.As(err, &ge) || !errors.Is(err, genErr) {
+			t.Errorf("Expected GeneratorError wrapping the generator error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+)
+
+// TestGetOrRefreshEntry tests generator-supplied TTLs and NoCache on every fill path.
+func TestGetOrRefreshEntry(t *testing.T) {
+	ctx := context.Background()
+	hourly := func(_ context.Context) (cache.Entry[string], error) {
+		return cache.Entry[string]{Value: "value", TTL: time.Hour}, nil
+	}
+	uncached := func(_ context.Context) (cache.Entry[string], error) {
+		return cache.Entry[string]{Value: "value", NoCache: true}, nil
+	}
+
+	policies := []cache.MissFillPolicy{
+		cache.MissFillSync,
+		cache.MissFillAsync,
+		cache.MissFillStaleOrSync,
+		cache.MissFillCooperative,
+		cache.MissFillCoalesce,
+	}
+	for _, p := range policies {
+		t.Run("Entry TTL "+p.String(), func(t *testing.T) {
+			b := cache.NewMemoryBackend()
+			h, _ := cache.NewWithBackend[string](b, cache.WithMissFillPolicy(p), cache.WithDefaultTTL(time.Minute))
+
+			result, err := h.GetOrRefreshEntry(ctx, "key", hourly, cache.WithTTL(2*time.Minute))
+			if err != nil || result.Value != "value" || result.FromCache {
+				t.Fatalf("Expected generated value, got %+v, %v", result, err)
+			}
+			_ = h.WaitIdle(ctx)
+			if ttl, err := b.TTL(ctx, "key"); err != nil || ttl <= 2*time.Minute {
+				t.Errorf("Expected the entry's one-hour TTL, got %v, %v", ttl, err)
+			}
+		})
+
+		t.Run("NoCache "+p.String(), func(t *testing.T) {
+			b := cache.NewMemoryBackend()
+			h, _ := cache.NewWithBackend[string](b, cache.WithMissFillPolicy(p))
+
+			result, err := h.GetOrRefreshEntry(ctx, "key", uncached)
+			if err != nil || result.Value != "value" {
+				t.Fatalf("Expected generated value, got %+v, %v", result, err)
+			}
+			_ = h.WaitIdle(ctx)
+			if exists, _ := b.Exists(ctx, "key"); exists {
+				t.Error("Expected NoCache entry not to be stored")
+			}
+		})
+	}
+
+	t.Run("Background refresh", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		h, _ := cache.NewWithBackend[string](b, cache.WithDefaultTTL(time.Minute))
+		_ = h.Set(ctx, "key", "old")
+
+		result, err := h.GetOrRefreshEntry(ctx, "key", hourly)
+		if err != nil || !result.FromCache || result.Value != "old" {
+			t.Fatalf("Expected cached value, got %+v, %v", result, err)
+		}
+		_ = h.WaitIdle(ctx)
+		if ttl, _ := b.TTL(ctx, "key"); ttl <= time.Minute {
+			t.Errorf("Expected the background refresh to store the entry's TTL, got %v", ttl)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCacheMiss is returned when MissFillFailFast is active and the key is not in the cache.
+var ErrCacheMiss = errors.New("cache miss")
+
+// ErrNotFound is returned by Get when the key does not exist in the backing store.
+// It replaces the raw redis.Nil that earlier versions leaked to callers.
+var ErrNotFound = errors.New("cache: key not found")
+
+// ErrReadOnly is returned by the write methods of a cache wrapped with ReadOnly.
+var ErrReadOnly = errors.New("cache: read-only")
+
+// ErrInvalidConfig is matched by every error returned from Config.Validate and
+// by constructors given a contradictory configuration.
+var ErrInvalidConfig = errors.New("cache: invalid config")
+
+// ErrProfileNotFound is returned when a named configuration profile does not exist.
+var ErrProfileNotFound = errors.New("cache: profile not found")
+
+// ErrConflict is returned by Update when every attempt lost a race against
+// another writer of the same key.
+var ErrConflict = errors.New("cache: update conflict")
+
+// ErrAtomicUnsupported is returned by features that need an AtomicBackend
+// when the handler's backend does not implement it.
+var ErrAtomicUnsupported = errors.New("cache: backend does not support atomic writes")
+
+// ErrLeaseHeld is returned by MissFillLease when another caller holds the fill
+// lease and neither a stale copy nor the filled value became available within
+// the lease wait. Retry shortly. It matches ErrCacheMiss and, like it, is never
+// suppressed by ErrorPolicyZeroValue.
+var ErrLeaseHeld = fmt.Errorf("%w: fill lease held by another caller, try again", ErrCacheMiss)
+
+// ErrWriteBehindClosed is returned by WriteBehind.Set after Close.
+var ErrWriteBehindClosed = errors.New("cache: write-behind closed")
+
+// GeneratorError reports a failure of the caller-supplied Generator.
+type GeneratorError struct {
+	Key string // Full cache key (including prefix) the value was generated for
+	Err error  // Error returned by the generator
+}
+
+func (e *GeneratorError) Error() string {
+	return fmt.Sprintf("cache: generator for %q: %v", e.Key, e.Err)
+}
+
+func (e *GeneratorError) Unwrap() error { return e.Err }
+
+// BackendError reports a failure of the backing store (Redis) while executing Op on Key.
+type BackendError struct {
+	Op  string // Store operation, e.g. "get", "set", "ttl"
+	Key string // Full cache key (including prefix)
+	Err error  // Error returned by the store client
+}
+
+func (e *BackendError) Error() string {
+	return fmt.Sprintf("cache: backend %s %q: %v", e.Op, e.Key, e.Err)
+}
+
+func (e *BackendError) Unwrap() error { return e.Err }
+
+// DecodeError reports that a stored value could not be decoded into T.
+type DecodeError struct {
+	Op  string // Decode step, e.g. "bytes" or "unmarshal"
+	Key string // Full cache key (including prefix)
+	Err error  // Underlying decode error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("cache: decode %s %q: %v", e.Op, e.Key, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// EncodeError reports that a value could not be encoded for storage.
+type EncodeError struct {
+	Key string // Full cache key (including prefix)
+	Err error  // Underlying encode error
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("cache: encode %q: %v", e.Key, e.Err)
+}
+
+func (e *EncodeError) Unwrap() error { return e.Err }
+
+// PersistError reports a failure of the caller-supplied PersistFunc.
+type PersistError struct {
+	Key      string // Full cache key (including prefix) the value was persisted for
+	Attempts int    // Number of attempts made
+	Err      error  // Error returned by the last attempt, joined with context.Canceled if Close abandoned the write
+}
+
+func (e *PersistError) Error() string {
+	return fmt.Sprintf("cache: persist %q after %d attempt(s): %v", e.Key, e.Attempts, e.Err)
+}
+
+func (e *PersistError) Unwrap() error { return e.Err }
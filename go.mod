@@ -7,7 +7,8 @@ require (
 )
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/go-redis/redismock/v9 v9.2.0
 	github.com/redis/go-redis/v9 v9.14.0
-	github.com/joho/godotenv v1.5.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -4,11 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"math/rand/v2"
 	"time"
-
-	"github.com/redis/go-redis/v9"
 )
 
 // ---------------------------
@@ -17,7 +14,8 @@ import (
 
 // missSyncWriteThenReturn handles a cache miss by synchronously generating a value and writing it to the cache.
 // It acquires a per-key lock to prevent concurrent writes, double-checks the cache after locking, and generates
-// the value using the provided Generator if still missing. On generation error or cache write failure, it returns
+// the value using the provided Generator if still missing. Waiting for the lock honours ctx: a cancelled caller
+// returns ctx.Err() without ever holding the lock. On generation error or cache write failure, it returns
 // a zero-valued Result with the error. On success, it returns the generated value with FromCache set to false.
 //
 // Parameters:
@@ -28,39 +26,66 @@ import (
 //
 // Returns:
 //   - Result[T]: The result containing the generated value or a zero value on error.
-//   - error: Any error from the cache check, generation, or cache write.
+//   - error: Any error from waiting for the lock, the cache check, generation, or cache write.
 func (h *Handler[T]) missSyncWriteThenReturn(
 	ctx context.Context,
 	key string,
 	ttl time.Duration,
-	gen Generator[T],
+	gen EntryGenerator[T],
 ) (Result[T], error) {
-	var err error
-	var v T
-	var res Result[T]
 	var zero T
-	fullKey := h.fullKey(key)
 
 	// Acquire per-key lock
-	unlock := h.localLocks.Lock(fullKey)
+	unlock, err := h.core.localLocks.LockContext(ctx, h.fullKey(key))
+	if err != nil {
+		return Result[T]{Value: zero}, err
+	}
 	defer unlock()
 
-	// Double-check after acquiring lock
-	if res, err = h.Get(ctx, key); err == nil {
+	return h.generateAndStore(ctx, key, ttl, gen)
+}
+
+// generateAndStore double-checks the cache and, if the key is still missing, generates
+// the value and writes it. The caller must already be the only writer for the key in
+// this process (holding the per-key lock or leading a coalesced flight).
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeouts.
+//   - key: Cache key to check and store the value.
+//   - ttl: Time-to-live duration for the cached value.
+//   - gen: Generator function to produce the value on cache miss.
+//
+// Returns:
+//   - Result[T]: The cached or generated value, or a zero value on error.
+//   - error: Any error from the cache check, generation, or cache write.
+func (h *Handler[T]) generateAndStore(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	gen EntryGenerator[T],
+) (Result[T], error) {
+	var err error
+	var e Entry[T]
+	var res Result[T]
+	var zero T
+
+	// Double-check: the previous holder may have filled the key.
+	if res, err = h.get(ctx, key); err == nil {
 		return res, nil
-	} else if !errors.Is(err, redis.Nil) {
+	} else if !errors.Is(err, ErrNotFound) {
 		return Result[T]{Value: zero}, err
 	}
 
 	// Still missing; generate and write
-	v, err = gen(ctx)
+	version := h.newVersion()
+	e, err = gen(ctx)
 	if err != nil {
-		return Result[T]{Value: zero}, fmt.Errorf("generator: %w", err)
+		return Result[T]{Value: zero}, &GeneratorError{Key: h.fullKey(key), Err: err}
 	}
-	if err = h.Set(ctx, key, v, WithTTL(ttl)); err != nil {
+	if err = h.storeEntry(ctx, key, ttl, e, version); err != nil {
 		return Result[T]{Value: zero}, err
 	}
-	return Result[T]{Value: v, FromCache: false, CachedAt: time.Now()}, nil
+	return Result[T]{Value: e.Value, FromCache: false, CachedAt: h.now()}, nil
 }
 
 // missReturnThenAsyncWrite handles a cache miss by generating a value and returning it immediately,
@@ -80,15 +105,18 @@ func (h *Handler[T]) missReturnThenAsyncWrite(
 	ctx context.Context,
 	key string,
 	ttl time.Duration,
-	gen Generator[T],
+	gen EntryGenerator[T],
 ) (Result[T], error) {
 	var zero T
-	v, err := gen(ctx)
+	version := h.newVersion()
+	e, err := gen(ctx)
 	if err != nil {
-		return Result[T]{Value: zero}, fmt.Errorf("generator: %w", err)
+		return Result[T]{Value: zero}, &GeneratorError{Key: h.fullKey(key), Err: err}
 	}
-	go h.spawnBackgroundMissWrite(key, ttl, v)
-	return Result[T]{Value: v, FromCache: false, CachedAt: time.Now()}, nil
+	if !e.NoCache {
+		h.background(func() { h.spawnBackgroundMissWrite(key, entryTTL(e, ttl), e.Value, version) })
+	}
+	return Result[T]{Value: e.Value, FromCache: false, CachedAt: h.now()}, nil
 }
 
 // spawnBackgroundMissWrite persists a generated value to the cache in the background after a cache miss.
@@ -100,26 +128,27 @@ func (h *Handler[T]) missReturnThenAsyncWrite(
 //   - key: Cache key to store the value.
 //   - ttl: Time-to-live duration for the cached value.
 //   - v: The value to cache.
-func (h *Handler[T]) spawnBackgroundMissWrite(key string, ttl time.Duration, v T) {
+//   - version: Version for versioned writes, taken before v was generated.
+func (h *Handler[T]) spawnBackgroundMissWrite(key string, ttl time.Duration, v T, version int64) {
 	ctx, cancel := context.WithTimeout(context.Background(), h.config.bgRefreshTimeout)
 	defer cancel()
 
 	fullKey := h.fullKey(key)
 
 	// Try-lock: if someone else is writing, skip.
-	unlock, ok := h.localLocks.TryLock(fullKey)
+	unlock, ok := h.core.localLocks.TryLock(fullKey)
 	if !ok {
 		return
 	}
 	defer unlock()
 
 	// Double-check if key is now present.
-	exists, err := h.config.rdb.Exists(ctx, fullKey).Result()
-	if err != nil || exists > 0 {
+	present, err := h.keyPresent(ctx, fullKey)
+	if err != nil || present {
 		return
 	}
 
-	_ = h.Set(ctx, key, v, WithTTL(ttl))
+	_ = h.setVersion(ctx, key, v, ttl, version)
 }
 
 // ---------------------------
@@ -136,14 +165,14 @@ func (h *Handler[T]) spawnBackgroundMissWrite(key string, ttl time.Duration, v T
 //   - key: Cache key to refresh.
 //   - ttl: Time-to-live duration for the updated value.
 //   - gen: Generator function to produce the new value.
-func (h *Handler[T]) spawnBackgroundRefresh(key string, ttl time.Duration, gen Generator[T]) {
+func (h *Handler[T]) spawnBackgroundRefresh(key string, ttl time.Duration, gen EntryGenerator[T]) {
 	ctx, cancel := context.WithTimeout(context.Background(), h.config.bgRefreshTimeout)
 	defer cancel()
 
 	fullKey := h.fullKey(key)
 
 	// Try-lock: if someone else is refreshing, skip.
-	unlock, ok := h.localLocks.TryLock(fullKey)
+	unlock, ok := h.core.localLocks.TryLock(fullKey)
 	if !ok {
 		return
 	}
@@ -155,11 +184,13 @@ func (h *Handler[T]) spawnBackgroundRefresh(key string, ttl time.Duration, gen G
 	}
 
 	// Generate and update
-	v, err := gen(ctx)
+	version := h.newVersion()
+	e, err := gen(ctx)
+	h.emit(EventBackgroundRefresh, fullKey, err)
 	if err != nil {
 		return
 	}
-	_ = h.Set(ctx, key, v, WithTTL(ttl))
+	_ = h.storeEntry(ctx, key, ttl, e, version)
 }
 
 // ---------------------------
@@ -179,13 +210,13 @@ func (h *Handler[T]) shouldRefreshNow(fullKey string) bool {
 	if h.config.refreshCooldown <= 0 {
 		return true
 	}
-	h.lastRefreshMu.Lock()
-	defer h.lastRefreshMu.Unlock()
-	last, ok := h.lastRefreshByKey[fullKey]
+	h.core.lastRefreshMu.Lock()
+	defer h.core.lastRefreshMu.Unlock()
+	last, ok := h.core.lastRefreshByKey[fullKey]
 	if !ok {
 		return true
 	}
-	return time.Since(last) >= h.config.refreshCooldown
+	return h.since(last) >= h.config.refreshCooldown
 }
 
 // setLastRefreshNow records the current time as the last refresh time for a cache key.
@@ -198,9 +229,9 @@ func (h *Handler[T]) setLastRefreshNow(fullKey string) {
 	if h.config.refreshCooldown <= 0 && h.config.missDeduplicationWindow <= 0 {
 		return
 	}
-	h.lastRefreshMu.Lock()
-	h.lastRefreshByKey[fullKey] = time.Now()
-	h.lastRefreshMu.Unlock()
+	h.core.lastRefreshMu.Lock()
+	h.core.lastRefreshByKey[fullKey] = h.now()
+	h.core.lastRefreshMu.Unlock()
 }
 
 // ---------------------------
@@ -223,13 +254,13 @@ func (h *Handler[T]) checkDeduplicationBypass(ctx context.Context, key string, t
 		window = ttl
 	}
 	fullKey := h.fullKey(key)
-	h.lastRefreshMu.Lock()
-	last, ok := h.lastRefreshByKey[fullKey]
-	h.lastRefreshMu.Unlock()
-	if !ok || time.Since(last) >= window {
+	h.core.lastRefreshMu.Lock()
+	last, ok := h.core.lastRefreshByKey[fullKey]
+	h.core.lastRefreshMu.Unlock()
+	if !ok || h.since(last) >= window {
 		return Result[T]{}, false
 	}
-	res, err := h.Get(ctx, key)
+	res, err := h.get(ctx, key)
 	if err == nil {
 		return res, true
 	}
@@ -257,7 +288,7 @@ func (h *Handler[T]) missStaleWhileRevalidate(
 	ctx context.Context,
 	key string,
 	ttl time.Duration,
-	gen Generator[T],
+	gen EntryGenerator[T],
 	co callOpts,
 ) (Result[T], error) {
 	staleKey := h.fullKey(key + ":stale")
@@ -276,9 +307,9 @@ func (h *Handler[T]) missStaleWhileRevalidate(
 		// only when background refresh is enabled (disableHitRefresh respects
 		// C/FFI callers that have not registered a persistent generator).
 		if !co.disableHitRefresh {
-			go h.spawnStaleRefresh(key, ttl, gen)
+			h.background(func() { h.spawnStaleRefresh(key, ttl, gen) })
 		}
-		return Result[T]{Value: staleResult, FromCache: true, CachedAt: time.Now()}, nil
+		return Result[T]{Value: staleResult, FromCache: true, CachedAt: h.now()}, nil
 	}
 
 	// No stale data, fall back to sync generation
@@ -294,8 +325,9 @@ func (h *Handler[T]) missFailFast(_ context.Context, _ string) (Result[T], error
 
 // missCooperativeRefresh handles a cache miss by allowing concurrent requests to wait for the first
 // request to complete generation, using a lock with a timeout (cooperativeTimeout). If the lock is
-// acquired, it performs synchronous generation via missSyncWriteThenReturn. If the lock times out,
-// it generates the value immediately without caching to avoid blocking.
+// acquired, it keeps holding it while performing the double-check, generation and write. If the wait
+// times out, it generates the value immediately without caching to avoid blocking; a timed-out waiter
+// never acquires the lock later. If the caller's own ctx is cancelled, ctx.Err() is returned.
 //
 // Parameters:
 //   - ctx: Context for cancellation and timeouts.
@@ -305,50 +337,81 @@ func (h *Handler[T]) missFailFast(_ context.Context, _ string) (Result[T], error
 //
 // Returns:
 //   - Result[T]: The result containing the generated value (from sync or immediate generation).
-//   - error: Any error from the value generation.
+//   - error: Any error from the value generation, or the caller's context error.
 func (h *Handler[T]) missCooperativeRefresh(
 	ctx context.Context,
 	key string,
 	ttl time.Duration,
-	gen Generator[T],
+	gen EntryGenerator[T],
 ) (Result[T], error) {
 	var zero T
-	var err error
-	var v T
 	fullKey := h.fullKey(key)
 
 	// Try to acquire lock with timeout
 	lockCtx, cancel := context.WithTimeout(ctx, h.config.cooperativeTimeout)
 	defer cancel()
 
-	done := make(chan struct{})
-	go func() {
-		unlock := h.localLocks.Lock(fullKey)
-		defer unlock()
-		close(done)
-	}()
-
-	select {
-	case <-lockCtx.Done():
+	unlock, err := h.core.localLocks.LockContext(lockCtx, fullKey)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return Result[T]{Value: zero}, ctxErr
+		}
 		// Timeout waiting for lock, fall back to immediate generation
-		v, err = gen(ctx)
-		if err != nil {
-			return Result[T]{Value: zero}, fmt.Errorf("generator: %w", err)
+		e, genErr := gen(ctx)
+		if genErr != nil {
+			return Result[T]{Value: zero}, &GeneratorError{Key: fullKey, Err: genErr}
 		}
-		return Result[T]{Value: v, FromCache: false, CachedAt: time.Now()}, nil
-	case <-done:
-		// Got lock, proceed with normal sync generation
-		return h.missSyncWriteThenReturn(ctx, key, ttl, gen)
+		return Result[T]{Value: e.Value, FromCache: false, CachedAt: h.now()}, nil
 	}
+	defer unlock()
+
+	// Got lock, proceed with normal sync generation
+	return h.generateAndStore(ctx, key, ttl, gen)
+}
+
+// missCoalesce handles a cache miss by sharing a single in-flight generation among all
+// concurrent callers for the same key in this process, across every view of the Core. The
+// shared execution takes the per-key lock, so it also serialises with MissFillSync and
+// MissFillCooperative fills, then double-checks the cache, generates the value, and writes
+// it before fanning the result (or error) out to every waiter. Each caller's ctx only
+// bounds its own wait; see doFlight.
+//
+// Parameters:
+//   - ctx: Context bounding how long this caller waits for the shared result.
+//   - key: Cache key to check and store the value.
+//   - ttl: Time-to-live duration for the cached value.
+//   - gen: Generator function to produce the value on cache miss.
+//
+// Returns:
+//   - Result[T]: The shared result or a zero value on error.
+//   - error: The shared error, or ctx.Err() if this caller stopped waiting.
+func (h *Handler[T]) missCoalesce(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	gen EntryGenerator[T],
+) (Result[T], error) {
+	fullKey := h.fullKey(key)
+	return doFlight(ctx, h.core.flights, fullKey, h.config.bgRefreshTimeout, func(flightCtx context.Context) (Result[T], error) {
+		// Serialise with MissFillSync and MissFillCooperative fills of the same key.
+		unlock, err := h.core.localLocks.LockContext(flightCtx, fullKey)
+		if err != nil {
+			var zero T
+			return Result[T]{Value: zero}, err
+		}
+		defer unlock()
+		return h.generateAndStore(flightCtx, key, ttl, gen)
+	})
 }
 
 // ---------------------------
 // Helper Methods for New Policies
 // ---------------------------
 
-// getFromKey retrieves a value from a specific Redis key, typically used for stale data.
+// getFromKey retrieves a value from a specific Redis key (main or stale).
 // It fetches the raw bytes from Redis, unmarshals them into type T, and returns the value.
-// On any error (Redis fetch or unmarshaling), it returns a zero value and the error.
+// On any error it returns a zero value and a typed error: ErrNotFound, *BackendError
+// or *DecodeError.
 //
 // Parameters:
 //   - ctx: Context for cancellation and timeouts.
@@ -358,25 +421,89 @@ func (h *Handler[T]) missCooperativeRefresh(
 //   - T: The unmarshaled value or a zero value on error.
 //   - error: Any error from the Redis fetch or unmarshaling.
 func (h *Handler[T]) getFromKey(ctx context.Context, fullKey string) (T, error) {
-	var zero T
-	var err error
-	var raw []byte
-	cmd := h.config.rdb.Get(ctx, fullKey)
-	if err = cmd.Err(); err != nil {
+	raw, err := h.getRaw(ctx, fullKey)
+	if err != nil {
+		var zero T
 		return zero, err
 	}
+	return h.decode(ctx, fullKey, raw)
+}
 
-	raw, err = cmd.Bytes()
+// decode unmarshals raw bytes read from fullKey into T, applying the
+// DecodeFailurePolicy on failure.
+func (h *Handler[T]) decode(ctx context.Context, fullKey string, raw []byte) (T, error) {
+	var v T
+	_, raw = splitVersion(raw)
+	if err := json.Unmarshal(raw, &v); err != nil {
+		var zero T
+		return zero, h.handleDecodeFailure(ctx, fullKey, &DecodeError{Op: "unmarshal", Key: fullKey, Err: err})
+	}
+	return v, nil
+}
+
+// getRaw fetches the raw bytes stored under fullKey, passing ErrNotFound through
+// and wrapping backend failures in *BackendError.
+func (h *Handler[T]) getRaw(ctx context.Context, fullKey string) ([]byte, error) {
+	raw, err := h.reader(fullKey).Get(ctx, fullKey)
 	if err != nil {
-		return zero, err
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, &BackendError{Op: "get", Key: fullKey, Err: err}
 	}
+	return raw, nil
+}
 
-	var v T
-	if err = json.Unmarshal(raw, &v); err != nil {
-		return zero, err
+// handleDecodeFailure reports a decode failure to observers and applies the
+// configured DecodeFailurePolicy. It returns the error the lookup should yield:
+// the original *DecodeError for DecodeFailureSurface, ErrNotFound otherwise.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeouts.
+//   - fullKey: The full Redis key (including prefix) whose value failed to decode.
+//   - decodeErr: The decode error.
+//
+// Returns:
+//   - error: The error to return from the lookup.
+func (h *Handler[T]) handleDecodeFailure(ctx context.Context, fullKey string, decodeErr *DecodeError) error {
+	h.emit(EventDecodeFailure, fullKey, decodeErr)
+
+	switch h.config.decodeFailurePolicy {
+	case DecodeFailureMiss:
+		return ErrNotFound
+	case DecodeFailureDelete:
+		// Best effort: a failed delete still leaves the entry to be overwritten by the fill.
+		_ = h.config.backend.Delete(ctx, fullKey)
+		return ErrNotFound
+	default: // DecodeFailureSurface
+		return decodeErr
 	}
+}
 
-	return v, nil
+// keyPresent reports whether a usable entry exists for fullKey on the primary
+// backend. Under DecodeFailureMiss an undecodable entry still exists in Redis
+// but must not count as present, otherwise background writes would never
+// replace it.
+func (h *Handler[T]) keyPresent(ctx context.Context, fullKey string) (bool, error) {
+	if h.config.decodeFailurePolicy == DecodeFailureMiss {
+		raw, err := h.config.backend.Get(ctx, fullKey)
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, &BackendError{Op: "get", Key: fullKey, Err: err}
+		}
+		_, err = h.decode(ctx, fullKey, raw)
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	exists, err := h.config.backend.Exists(ctx, fullKey)
+	if err != nil {
+		return false, &BackendError{Op: "exists", Key: fullKey, Err: err}
+	}
+	return exists, nil
 }
 
 // spawnStaleRefresh refreshes both the main and stale cache keys in the background.
@@ -389,33 +516,63 @@ func (h *Handler[T]) getFromKey(ctx context.Context, fullKey string) (T, error)
 //   - key: Cache key to refresh (main and stale).
 //   - ttl: Time-to-live duration for the main cache entry.
 //   - gen: Generator function to produce the new value.
-func (h *Handler[T]) spawnStaleRefresh(key string, ttl time.Duration, gen Generator[T]) {
+func (h *Handler[T]) spawnStaleRefresh(key string, ttl time.Duration, gen EntryGenerator[T]) {
 	ctx, cancel := context.WithTimeout(context.Background(), h.config.bgRefreshTimeout)
 	defer cancel()
 
 	fullKey := h.fullKey(key)
 	staleKey := h.fullKey(key + ":stale")
 
-	unlock, ok := h.localLocks.TryLock(fullKey)
+	unlock, ok := h.core.localLocks.TryLock(fullKey)
 	if !ok {
 		return
 	}
 	defer unlock()
 
 	// Generate new data
-	v, err := gen(ctx)
-	if err != nil {
+	version := h.newVersion()
+	e, err := gen(ctx)
+	h.emit(EventBackgroundRefresh, fullKey, err)
+	if err != nil || e.NoCache {
 		return
 	}
 
 	// Update main key
-	_ = h.Set(ctx, key, v, WithTTL(ttl))
+	_ = h.setVersion(ctx, key, e.Value, entryTTL(e, ttl), version)
 
 	// Update stale key with longer TTL
-	_ = h.setToKey(ctx, staleKey, v, h.config.staleDataTTL)
+	_ = h.setToKey(ctx, staleKey, e.Value, h.config.staleDataTTL)
+}
+
+// storeEntry writes a generated entry under key, using the entry's own TTL when
+// it has one and ttl otherwise. Entries marked NoCache are not written.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeouts.
+//   - key: Cache key to store the value.
+//   - ttl: Fallback time-to-live from the call or handler.
+//   - e: The generated entry.
+//   - version: Version for versioned writes, taken before the generator ran (see newVersion).
+//
+// Returns:
+//   - error: Any error from encoding or the cache write.
+func (h *Handler[T]) storeEntry(ctx context.Context, key string, ttl time.Duration, e Entry[T], version int64) error {
+	if e.NoCache {
+		return nil
+	}
+	return h.setVersion(ctx, key, e.Value, entryTTL(e, ttl), version)
+}
+
+// entryTTL returns the TTL an entry should be stored with.
+func entryTTL[T any](e Entry[T], fallback time.Duration) time.Duration {
+	if e.TTL > 0 {
+		return e.TTL
+	}
+	return fallback
 }
 
-// setToKey sets a value to a specific Redis key with the specified TTL.
+// setToKey sets a value to a specific Redis key with the specified TTL, after
+// applying the configured TTL jitter.
 // It marshals the value to JSON and stores it in Redis, returning any error
 // from marshaling or the Redis operation.
 //
@@ -426,13 +583,17 @@ func (h *Handler[T]) spawnStaleRefresh(key string, ttl time.Duration, gen Genera
 //   - ttl: Time-to-live duration for the key.
 //
 // Returns:
-//   - error: Any error from JSON marshaling or the Redis set operation.
+//   - error: An *EncodeError from JSON marshaling or a *BackendError from the Redis set operation.
 func (h *Handler[T]) setToKey(ctx context.Context, fullKey string, value T, ttl time.Duration) error {
 	b, err := json.Marshal(value)
 	if err != nil {
-		return err
+		return &EncodeError{Key: fullKey, Err: err}
+	}
+	if err = h.config.backend.Set(ctx, fullKey, b, h.jitterTTL(fullKey, ttl)); err != nil {
+		return &BackendError{Op: "set", Key: fullKey, Err: err}
 	}
-	return h.config.rdb.Set(ctx, fullKey, b, ttl).Err()
+	h.recordWrite(fullKey)
+	return nil
 }
 
 // shouldProbabilisticRefresh determines if a cache key should be refreshed based on a
@@ -450,15 +611,15 @@ func (h *Handler[T]) setToKey(ctx context.Context, fullKey string, value T, ttl
 func (h *Handler[T]) shouldProbabilisticRefresh(key string, ttl time.Duration, beta float64) bool {
 	fullKey := h.fullKey(key)
 
-	h.lastRefreshMu.Lock()
-	created, exists := h.lastRefreshByKey[fullKey+"@created"]
-	h.lastRefreshMu.Unlock()
+	h.core.lastRefreshMu.Lock()
+	created, exists := h.core.lastRefreshByKey[fullKey+"@created"]
+	h.core.lastRefreshMu.Unlock()
 
 	if !exists {
 		return false
 	}
 
-	age := time.Since(created)
+	age := h.since(created)
 	ageRatio := float64(age) / float64(ttl)
 
 	// Probabilistic formula: random() < (age / ttl) * beta
@@ -480,7 +641,7 @@ func (h *Handler[T]) handleHitRefresh(
 	ctx context.Context,
 	key string,
 	ttl time.Duration,
-	gen Generator[T],
+	gen EntryGenerator[T],
 	hitRefresh HitRefreshPolicy,
 	co callOpts,
 ) {
@@ -493,7 +654,7 @@ func (h *Handler[T]) handleHitRefresh(
 			threshold = h.config.defaultRefreshAheadThreshold
 		}
 		if h.shouldRefreshAhead(ctx, fullKey, ttl, threshold) {
-			go h.spawnBackgroundRefresh(key, ttl, gen)
+			h.background(func() { h.spawnBackgroundRefresh(key, ttl, gen) })
 		}
 
 	case HitRefreshProbabilistic:
@@ -502,7 +663,7 @@ func (h *Handler[T]) handleHitRefresh(
 			beta = h.config.defaultProbabilisticBeta
 		}
 		if h.shouldProbabilisticRefresh(key, ttl, beta) {
-			go h.spawnBackgroundRefresh(key, ttl, gen)
+			h.background(func() { h.spawnBackgroundRefresh(key, ttl, gen) })
 		}
 
 	case HitRefreshOlderThan:
@@ -511,7 +672,7 @@ func (h *Handler[T]) handleHitRefresh(
 			age = h.config.defaultRefreshOlderThanAge
 		}
 		if age > 0 && h.shouldRefreshOlderThan(ctx, fullKey, ttl, age) {
-			go h.spawnBackgroundRefresh(key, ttl, gen)
+			h.background(func() { h.spawnBackgroundRefresh(key, ttl, gen) })
 		}
 
 	case HitRefreshNone:
@@ -519,7 +680,7 @@ func (h *Handler[T]) handleHitRefresh(
 
 	default: // HitRefreshDefault
 		if h.shouldRefreshNow(fullKey) {
-			go h.spawnBackgroundRefresh(key, ttl, gen)
+			h.background(func() { h.spawnBackgroundRefresh(key, ttl, gen) })
 		}
 	}
 }
@@ -541,7 +702,8 @@ func (h *Handler[T]) shouldRefreshOlderThan(
 	fullKey string,
 	originalTTL, threshold time.Duration,
 ) bool {
-	remaining, err := h.config.rdb.TTL(ctx, fullKey).Result()
+	fullKey, originalTTL = h.ageReference(fullKey, originalTTL)
+	remaining, err := h.reader(fullKey).TTL(ctx, fullKey)
 	if err != nil || remaining <= 0 {
 		return false
 	}
@@ -568,7 +730,8 @@ func (h *Handler[T]) shouldRefreshAhead(
 	threshold float64,
 ) bool {
 	// Get remaining TTL from Redis
-	remaining, err := h.config.rdb.TTL(ctx, fullKey).Result()
+	fullKey, originalTTL = h.ageReference(fullKey, originalTTL)
+	remaining, err := h.reader(fullKey).TTL(ctx, fullKey)
 	if err != nil || remaining <= 0 {
 		return false
 	}
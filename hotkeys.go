@@ -0,0 +1,390 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------
+// Hot key detection
+// ---------------------------
+
+// WithHotKeyDetection counts reads per key over a sliding window and tracks
+// the topK most read keys; see HotKeys. Counting is sampled (see
+// WithHotKeySampleRate) and bounded: at most max(64*topK, 1024) keys are
+// counted per window, and once that is reached a new key displaces a rarely
+// read one. Views of one Core share the counters and should use the same
+// window.
+func WithHotKeyDetection(window time.Duration, topK int) Option {
+	return func(c *handlerConfig) {
+		c.hotKeyWindow = window
+		c.hotKeyTopK = topK
+	}
+}
+
+// WithHotKeySampleRate sets the fraction of reads that are counted, within
+// (0, 1]. Counts are scaled back up, so HotKey.Reads stays an estimate of all
+// reads. Lower rates cost less on busy handlers: an unsampled read of a key
+// outside the top-K set skips the shared counters entirely.
+func WithHotKeySampleRate(rate float64) Option {
+	return func(c *handlerConfig) { c.hotKeySampleRate = rate }
+}
+
+// WithHotKeyPromotion serves top-K keys read at least minReads times per
+// window from an in-process copy that lives for localTTL, taking their load
+// off the Redis shard that owns them. Writes and deletes through this process
+// drop the copy at once; writes from other processes are seen after at most
+// localTTL. Under sliding expiration, local hits still extend the stored
+// entry, in the background and at most once per tenth of the TTL. Requires
+// WithHotKeyDetection.
+func WithHotKeyPromotion(minReads float64, localTTL time.Duration) Option {
+	return func(c *handlerConfig) {
+		c.hotKeyThreshold = minReads
+		c.hotKeyLocalTTL = localTTL
+	}
+}
+
+// HotKey is one entry of a hot key report.
+type HotKey struct {
+	Key      string  // Full cache key (including prefix)
+	Reads    float64 // Estimated reads over the last window
+	Promoted bool    // Currently served from an in-process copy
+}
+
+// HotKeys returns the currently tracked hottest keys, hottest first. It is
+// empty unless WithHotKeyDetection is enabled.
+func (h *Handler[T]) HotKeys() []HotKey {
+	h = h.pin()
+	if h.config.hotKeyWindow <= 0 {
+		return nil
+	}
+	return h.core.hotKeys.report(h.now(), h.config.hotKeyWindow, h.config.hotKeyTopK)
+}
+
+// HotKeys returns the hottest keys read through any view of the core, using
+// the window and top-K of the view that last counted a read. It is empty until
+// a view with WithHotKeyDetection has been read.
+func (c *Core) HotKeys() []HotKey {
+	window, topK := c.hotKeys.settings()
+	if window <= 0 {
+		return nil
+	}
+	return c.hotKeys.report(c.config.clock.Now(), window, topK)
+}
+
+// hotLookup is lookup with hot key counting and, for promoted keys, the
+// in-process copy.
+func (h *Handler[T]) hotLookup(ctx context.Context, key string) (Result[T], error) {
+	fullKey := h.fullKey(key)
+	now := h.now()
+	weight := 0.0
+	if rate := h.config.hotKeySampleRate; rate >= 1 || rand.Float64() < rate { //nolint:gosec // Sampling does not need a cryptographic source
+		weight = 1 / rate
+	}
+	est := h.core.hotKeys.record(fullKey, weight, now, h.config.hotKeyWindow, h.config.hotKeyTopK)
+	hot := est >= h.config.hotKeyThreshold
+
+	if est >= 0 { // Only top-K keys have local copies
+		if res, ok := h.localHit(fullKey, now); ok {
+			return res, nil
+		}
+	}
+
+	res, err := h.get(ctx, key)
+	h.emitLookup(fullKey, err)
+	if err != nil || !hot || h.config.hotKeyLocalTTL <= 0 {
+		return res, err
+	}
+	if raw, merr := json.Marshal(res.Value); merr == nil &&
+		h.core.hotKeys.promote(fullKey, raw, now, h.config.hotKeyLocalTTL) {
+		h.emit(EventHotKeyPromoted, fullKey, nil)
+	}
+	return res, nil
+}
+
+// localHit serves fullKey from its in-process copy, if it has a usable one.
+func (h *Handler[T]) localHit(fullKey string, now time.Time) (Result[T], bool) {
+	raw, cachedAt, ok := h.core.hotKeys.localCopy(fullKey, now)
+	if !ok {
+		return Result[T]{}, false
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		h.core.hotKeys.forget(fullKey)
+		return Result[T]{}, false
+	}
+	h.emit(EventHit, fullKey, nil)
+	h.touchLocalHit(fullKey, now)
+	return Result[T]{Value: v, FromCache: true, CachedAt: cachedAt}, true
+}
+
+// touchLocalHit runs the sliding expiration touch for a read served from the
+// in-process copy, which never reaches the backend itself. Touches are
+// throttled per copy to one every tenth of the sliding TTL.
+func (h *Handler[T]) touchLocalHit(fullKey string, now time.Time) {
+	if h.sliding.maxLifetime <= 0 || !h.core.hotKeys.claimTouch(fullKey, now, h.sliding.ttl/10) {
+		return
+	}
+	h.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), h.config.bgRefreshTimeout)
+		defer cancel()
+		_, _ = h.getMainRaw(ctx, fullKey)
+	})
+}
+
+// hotKeyTracker keeps sliding-window read counts, the top-K set and the
+// in-process copies of promoted keys. It is shared by every view of a Core.
+//
+// Counts live in two fixed buckets, the current and the previous window; a
+// key's estimate is its current count plus the previous count weighted by the
+// part of the previous window still inside the sliding window. Only keys read
+// in the last two windows are kept, and at most hotKeyCandidates keys are
+// counted per window.
+type hotKeyTracker struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	window      time.Duration // Settings of the last view that counted a read
+	topK        int
+	cur, prev   map[string]float64
+	top         map[string]struct{}
+	tracked     sync.Map // Copy of top's keys, read without mu by unsampled reads
+	local       map[string]hotCopy
+}
+
+const (
+	// hotKeyMinCandidates is the smallest number of keys counted per window.
+	hotKeyMinCandidates = 1024
+	// hotKeyEvictionSamples is how many counted keys are compared to pick the
+	// one a new key displaces once the count map is full.
+	hotKeyEvictionSamples = 8
+)
+
+// hotKeyCandidates returns how many keys are counted per window for topK.
+func hotKeyCandidates(topK int) int {
+	return max(64*topK, hotKeyMinCandidates)
+}
+
+type hotCopy struct {
+	raw       []byte
+	cachedAt  time.Time
+	expiresAt time.Time
+	touchedAt time.Time // Last sliding expiration touch of the stored entry
+}
+
+func newHotKeyTracker() *hotKeyTracker {
+	return &hotKeyTracker{
+		cur:   make(map[string]float64),
+		top:   make(map[string]struct{}),
+		local: make(map[string]hotCopy),
+	}
+}
+
+// record adds weight reads of key and returns the key's estimate, or -1 when
+// the key is not in the top-K set. An unsampled read (weight 0) of a key
+// outside the top-K set returns without taking the lock.
+func (t *hotKeyTracker) record(key string, weight float64, now time.Time, window time.Duration, topK int) float64 {
+	if weight <= 0 {
+		if _, ok := t.tracked.Load(key); !ok {
+			return -1
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.window, t.topK = window, topK
+	t.rotate(now, window, topK)
+	if weight > 0 {
+		t.count(key, weight, topK)
+	}
+	est := t.estimate(key, now, window)
+	if _, ok := t.top[key]; ok {
+		return est
+	}
+	if est <= 0 {
+		return -1
+	}
+	if len(t.top) < topK {
+		t.track(key)
+		return est
+	}
+	coldest, coldestEst := "", est
+	for k := range t.top {
+		if e := t.estimate(k, now, window); e < coldestEst {
+			coldest, coldestEst = k, e
+		}
+	}
+	if coldest == "" {
+		return -1
+	}
+	t.untrack(coldest)
+	t.track(key)
+	return est
+}
+
+// count adds weight to key's current count. Once hotKeyCandidates keys are
+// counted, a new key displaces the smallest of a few sampled counts, so
+// memory stays bounded under high key cardinality while heavily read keys
+// keep their counts.
+func (t *hotKeyTracker) count(key string, weight float64, topK int) {
+	if _, ok := t.cur[key]; !ok && len(t.cur) >= hotKeyCandidates(topK) {
+		victim, least, n := "", math.Inf(1), 0
+		for k, c := range t.cur { // Map iteration starts at a random entry
+			if c < least {
+				victim, least = k, c
+			}
+			if n++; n == hotKeyEvictionSamples {
+				break
+			}
+		}
+		delete(t.cur, victim)
+	}
+	t.cur[key] += weight
+}
+
+// track adds key to the top-K set.
+func (t *hotKeyTracker) track(key string) {
+	t.top[key] = struct{}{}
+	t.tracked.Store(key, struct{}{})
+}
+
+// untrack removes key from the top-K set and drops its local copy.
+func (t *hotKeyTracker) untrack(key string) {
+	delete(t.top, key)
+	delete(t.local, key)
+	t.tracked.Delete(key)
+}
+
+// settings returns the window and top-K of the last view that counted a read.
+func (t *hotKeyTracker) settings() (time.Duration, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.window, t.topK
+}
+
+// rotate starts a new bucket once the current one is a full window old and
+// rebuilds the top-K set from the surviving counts.
+func (t *hotKeyTracker) rotate(now time.Time, window time.Duration, topK int) {
+	if t.windowStart.IsZero() {
+		t.windowStart = now
+		return
+	}
+	elapsed := now.Sub(t.windowStart)
+	if elapsed < window {
+		return
+	}
+	if elapsed < 2*window {
+		t.prev = t.cur
+	} else {
+		t.prev = nil
+	}
+	t.cur = make(map[string]float64)
+	t.windowStart = t.windowStart.Add(elapsed / window * window)
+
+	keys := make([]string, 0, len(t.prev))
+	for k := range t.prev {
+		keys = append(keys, k)
+	}
+	t.sortByEstimate(keys, now, window)
+	clear(t.top)
+	t.tracked.Clear()
+	for _, k := range keys[:min(topK, len(keys))] {
+		t.track(k)
+	}
+	for k := range t.local {
+		if _, ok := t.top[k]; !ok {
+			delete(t.local, k)
+		}
+	}
+}
+
+func (t *hotKeyTracker) estimate(key string, now time.Time, window time.Duration) float64 {
+	frac := float64(now.Sub(t.windowStart)) / float64(window)
+	return t.cur[key] + t.prev[key]*max(1-frac, 0)
+}
+
+func (t *hotKeyTracker) sortByEstimate(keys []string, now time.Time, window time.Duration) {
+	slices.SortFunc(keys, func(a, b string) int {
+		ea, eb := t.estimate(a, now, window), t.estimate(b, now, window)
+		switch {
+		case ea > eb:
+			return -1
+		case ea < eb:
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	})
+}
+
+// localCopy returns the unexpired in-process copy of key, if any.
+func (t *hotKeyTracker) localCopy(key string, now time.Time) ([]byte, time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c, ok := t.local[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	if !now.Before(c.expiresAt) {
+		delete(t.local, key)
+		return nil, time.Time{}, false
+	}
+	return c.raw, c.cachedAt, true
+}
+
+// promote stores an in-process copy of key if it is still in the top-K set.
+func (t *hotKeyTracker) promote(key string, raw []byte, now time.Time, ttl time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.top[key]; !ok {
+		return false
+	}
+	t.local[key] = hotCopy{raw: raw, cachedAt: now, expiresAt: now.Add(ttl), touchedAt: now}
+	return true
+}
+
+// claimTouch reports whether the stored entry behind key's copy is due for a
+// sliding expiration touch, at most one per every, and marks it touched.
+func (t *hotKeyTracker) claimTouch(key string, now time.Time, every time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c, ok := t.local[key]
+	if !ok || now.Sub(c.touchedAt) < every {
+		return false
+	}
+	c.touchedAt = now
+	t.local[key] = c
+	return true
+}
+
+// forget drops the in-process copy of key after a local write or delete.
+func (t *hotKeyTracker) forget(key string) {
+	t.mu.Lock()
+	delete(t.local, key)
+	t.mu.Unlock()
+}
+
+func (t *hotKeyTracker) report(now time.Time, window time.Duration, topK int) []HotKey {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rotate(now, window, topK)
+	keys := make([]string, 0, len(t.top))
+	for k := range t.top {
+		keys = append(keys, k)
+	}
+	t.sortByEstimate(keys, now, window)
+	out := make([]HotKey, 0, len(keys))
+	for _, k := range keys {
+		c, ok := t.local[k]
+		out = append(out, HotKey{
+			Key:      k,
+			Reads:    t.estimate(k, now, window),
+			Promoted: ok && now.Before(c.expiresAt),
+		})
+	}
+	return out
+}
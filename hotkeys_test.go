@@ -0,0 +1,139 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestHotKeys tests hot key detection and promotion to in-process copies.
+func TestHotKeys(t *testing.T) {
+	ctx := context.Background()
+
+	detect := cache.WithHotKeyDetection(time.Minute, 2)
+	everyRead := cache.WithHotKeySampleRate(1)
+	read := func(h *cachetest.Handler[string], key string, n int) {
+		for range n {
+			_, _ = h.Get(ctx, key)
+		}
+	}
+
+	t.Run("Reports the top keys", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](detect, everyRead)
+		for _, k := range []string{"flag", "home", "rare"} {
+			_ = h.Set(ctx, k, k)
+		}
+		read(h, "flag", 10)
+		read(h, "home", 6)
+		read(h, "rare", 1)
+
+		hot := h.HotKeys()
+		if len(hot) != 2 || hot[0].Key != "flag" || hot[1].Key != "home" {
+			t.Fatalf("Expected [flag home], got %+v", hot)
+		}
+		if hot[0].Reads != 10 || hot[0].Promoted {
+			t.Errorf("Expected 10 unpromoted reads of flag, got %+v", hot[0])
+		}
+
+		h.Clock.Advance(90 * time.Second) // Half of the previous window still counts
+		if hot = h.HotKeys(); len(hot) != 2 || hot[0].Reads != 5 {
+			t.Errorf("Expected flag's count to decay to 5, got %+v", hot)
+		}
+		h.Clock.Advance(2 * time.Minute)
+		if hot = h.HotKeys(); len(hot) != 0 {
+			t.Errorf("Expected idle keys to cool down, got %+v", hot)
+		}
+	})
+
+	t.Run("Promotes hot keys to a local copy", func(t *testing.T) {
+		var promoted []string
+		h, _ := cachetest.NewHandler[string](detect, everyRead,
+			cache.WithHotKeyPromotion(5, 10*time.Second),
+			cache.WithObserver(func(e cache.Event) {
+				if e.Kind == cache.EventHotKeyPromoted {
+					promoted = append(promoted, e.Key)
+				}
+			}),
+		)
+		_ = h.Set(ctx, "flag", "on")
+		read(h, "flag", 5)
+		if len(promoted) != 1 || promoted[0] != "flag" {
+			t.Fatalf("Expected flag to be promoted once, got %v", promoted)
+		}
+
+		_ = h.Backend.Set(ctx, "flag", []byte(`"off"`), time.Hour) // Written by another process
+		if r, _ := h.Get(ctx, "flag"); r.Value != "on" {
+			t.Errorf("Expected the local copy within its TTL, got %q", r.Value)
+		}
+		if hot := h.HotKeys(); !hot[0].Promoted {
+			t.Errorf("Expected flag to be reported as promoted, got %+v", hot)
+		}
+
+		h.Clock.Advance(11 * time.Second)
+		if r, _ := h.Get(ctx, "flag"); r.Value != "off" {
+			t.Errorf("Expected the local copy to expire, got %q", r.Value)
+		}
+
+		_ = h.Set(ctx, "flag", "on again")
+		if r, _ := h.Get(ctx, "flag"); r.Value != "on again" {
+			t.Errorf("Expected a local write to drop the copy, got %q", r.Value)
+		}
+		_ = h.Delete(ctx, "flag")
+		if _, err := h.Get(ctx, "flag"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected a local delete to drop the copy, got %v", err)
+		}
+	})
+
+	t.Run("Cold keys stay remote", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](detect, everyRead, cache.WithHotKeyPromotion(5, time.Minute))
+		_ = h.Set(ctx, "warm", "v1")
+		read(h, "warm", 4)
+		_ = h.Backend.Set(ctx, "warm", []byte(`"v2"`), time.Hour)
+		if r, _ := h.Get(ctx, "warm"); r.Value != "v2" {
+			t.Errorf("Expected a key under the threshold to be read from the backend, got %q", r.Value)
+		}
+	})
+
+	t.Run("Heavy keys survive a flood of cold keys", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](cache.WithHotKeyDetection(time.Minute, 1), everyRead)
+		read(h, "flag", 50)
+		for i := range 5000 {
+			read(h, fmt.Sprintf("cold-%d", i), 1)
+		}
+		if hot := h.HotKeys(); len(hot) != 1 || hot[0].Key != "flag" || hot[0].Reads != 50 {
+			t.Errorf("Expected flag to keep its 50 reads, got %+v", hot)
+		}
+	})
+
+	t.Run("Core report uses the view settings", func(t *testing.T) {
+		core, _ := cache.NewCoreWithBackend(cache.NewMemoryBackend())
+		if hot := core.HotKeys(); hot != nil {
+			t.Fatalf("Expected no report before any view counts, got %+v", hot)
+		}
+		view, _ := cache.Typed[string](core, "", detect, everyRead)
+		for range 3 {
+			_, _ = view.Get(ctx, "flag")
+		}
+		if hot := core.HotKeys(); len(hot) != 1 || hot[0].Key != "flag" || hot[0].Reads != 3 {
+			t.Errorf("Expected the view's counts in the core report, got %+v", hot)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		cfg := cache.DefaultConfig()
+		cfg.HotKeyLocalTTL = time.Second
+		if err := cfg.Validate(); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected promotion without detection to be rejected, got %v", err)
+		}
+		cfg.HotKeyWindow = time.Minute
+		cfg.HotKeySampleRate = 2
+		if err := cfg.Validate(); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected a sample rate above 1 to be rejected, got %v", err)
+		}
+	})
+}
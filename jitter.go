@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+)
+
+// WithTTLJitter spreads every TTL the handler writes by up to ±fraction of
+// its length (0.1 = ±10%), so keys written together do not expire together.
+// fraction must be within [0, 1).
+func WithTTLJitter(fraction float64) Option {
+	return func(c *handlerConfig) { c.ttlJitter = fraction }
+}
+
+// WithTTLJitterRange adds an offset drawn from [minOffset, maxOffset] to every TTL
+// the handler writes. minOffset may be negative. It combines with WithTTLJitter.
+func WithTTLJitterRange(minOffset, maxOffset time.Duration) Option {
+	return func(c *handlerConfig) {
+		c.ttlJitterMin = minOffset
+		c.ttlJitterMax = maxOffset
+	}
+}
+
+// WithDeterministicJitter derives the jitter from the full key instead of a
+// random source, so repeated writes of one key keep a stable expiry phase
+// while different keys are still spread out.
+func WithDeterministicJitter() Option {
+	return func(c *handlerConfig) { c.deterministicJitter = true }
+}
+
+// jitterTTL applies the configured TTL jitter to ttl for fullKey. It returns
+// ttl unchanged when jitter is disabled or would make the TTL non-positive.
+//
+// Parameters:
+//   - fullKey: The full cache key (including prefix) being written.
+//   - ttl: The TTL requested by the caller.
+//
+// Returns:
+//   - time.Duration: The TTL to store.
+func (h *Handler[T]) jitterTTL(fullKey string, ttl time.Duration) time.Duration {
+	c := h.config
+	if ttl <= 0 || (c.ttlJitter == 0 && c.ttlJitterMin == 0 && c.ttlJitterMax == 0) {
+		return ttl
+	}
+
+	var u float64 // Uniform in [0, 1)
+	if c.deterministicJitter {
+		f := fnv.New64a()
+		_, _ = f.Write([]byte(fullKey))
+		u = float64(f.Sum64()>>11) / (1 << 53)
+	} else {
+		u = rand.Float64() //nolint:gosec // Jitter does not need a cryptographic source
+	}
+
+	jittered := ttl
+	if c.ttlJitter > 0 {
+		jittered = time.Duration(float64(ttl) * (1 + c.ttlJitter*(2*u-1)))
+	}
+	jittered += c.ttlJitterMin + time.Duration(u*float64(c.ttlJitterMax-c.ttlJitterMin))
+	if jittered <= 0 {
+		return ttl
+	}
+	return jittered
+}
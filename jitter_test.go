@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestTTLJitter tests that written TTLs are spread and bounded.
+func TestTTLJitter(t *testing.T) {
+	ctx := context.Background()
+	hour := cache.WithDefaultTTL(time.Hour)
+
+	t.Run("Fraction", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](hour, cache.WithTTLJitter(0.1))
+		distinct := map[time.Duration]bool{}
+		for i := range 50 {
+			key := fmt.Sprintf("k%d", i)
+			_ = h.Set(ctx, key, "v")
+			ttl, _ := h.Backend.TTL(ctx, key)
+			if ttl < 54*time.Minute || ttl > 66*time.Minute {
+				t.Fatalf("Expected TTL within ±10%% of 1h, got %v", ttl)
+			}
+			distinct[ttl] = true
+		}
+		if len(distinct) < 2 {
+			t.Error("Expected jitter to spread TTLs")
+		}
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](hour, cache.WithTTLJitterRange(-time.Minute, 2*time.Minute))
+		for i := range 50 {
+			key := fmt.Sprintf("k%d", i)
+			_ = h.Set(ctx, key, "v", cache.WithTTL(10*time.Minute))
+			if ttl, _ := h.Backend.TTL(ctx, key); ttl < 9*time.Minute || ttl > 12*time.Minute {
+				t.Fatalf("Expected TTL within [9m, 12m], got %v", ttl)
+			}
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](hour, cache.WithTTLJitter(0.2), cache.WithDeterministicJitter())
+		_ = h.Set(ctx, "key", "v1")
+		first, _ := h.Backend.TTL(ctx, "key")
+		_ = h.Set(ctx, "key", "v2")
+		if second, _ := h.Backend.TTL(ctx, "key"); second != first {
+			t.Errorf("Expected stable jitter for one key, got %v then %v", first, second)
+		}
+	})
+
+	t.Run("Background writes", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](hour,
+			cache.WithTTLJitterRange(time.Minute, time.Minute),
+			cache.WithMissFillPolicy(cache.MissFillAsync),
+		)
+		_, _ = h.GetOrRefresh(ctx, "key", func(_ context.Context) (string, error) { return "v", nil })
+		_ = h.WaitIdle(ctx)
+		if ttl, _ := h.Backend.TTL(ctx, "key"); ttl != 61*time.Minute {
+			t.Errorf("Expected the async write to apply jitter, got %v", ttl)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		if _, err := cachetest.NewHandler[string](cache.WithTTLJitter(1)); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for fraction 1, got %v", err)
+		}
+		_, err := cachetest.NewHandler[string](cache.WithTTLJitterRange(time.Minute, -time.Minute))
+		if !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for an inverted range, got %v", err)
+		}
+	})
+}
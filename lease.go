@@ -0,0 +1,322 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ---------------------------
+// Lease-based fills
+// ---------------------------
+
+// leasePollInterval is how often a caller waiting on another pod's lease
+// re-reads the key.
+const leasePollInterval = 10 * time.Millisecond
+
+// LeaseBackend is implemented by backends that can fill a key under a lease,
+// which MissFillLease needs. Leases are acquired with AtomicBackend.SetNX.
+type LeaseBackend interface {
+	AtomicBackend
+
+	// FillLease stores value under key only if leaseKey still holds token and
+	// key still holds prev, and reports whether it did. A nil prev requires key
+	// to be absent. A matching lease is released either way.
+	FillLease(ctx context.Context, key string, value []byte, ttl time.Duration, leaseKey, token string, prev []byte) (bool, error)
+
+	// ReleaseLease deletes leaseKey if it still holds token.
+	ReleaseLease(ctx context.Context, leaseKey, token string) error
+}
+
+// WithLease configures MissFillLease. ttl bounds how long a lease blocks other
+// pods if its holder dies mid-fill and must be positive whatever the default
+// policy, since a call can still pick MissFillLease; wait is how long callers
+// without the lease poll for the holder's value before giving up with
+// ErrLeaseHeld.
+func WithLease(ttl, wait time.Duration) Option {
+	return func(c *handlerConfig) {
+		c.leaseTTL = ttl
+		c.leaseWait = wait
+	}
+}
+
+// WithLeaseStale makes MissFillLease keep a stale copy of every fill for
+// StaleDataTTL and serve it to callers that find the lease held, instead of
+// having them wait for the holder. Without it lease fills write only the key.
+func WithLeaseStale() Option {
+	return func(c *handlerConfig) { c.leaseStale = true }
+}
+
+// leaseKey returns the key holding the fill lease for fullKey. The hash tag
+// keeps it in the same Redis Cluster slot as fullKey.
+func leaseKey(fullKey string) string {
+	return "{" + fullKey + "}:lease"
+}
+
+// missLease handles a cache miss with a lease stored next to the key, so one
+// caller across all pods generates the value. Callers in this process share
+// one attempt (see missCoalesce). The lease holder generates and writes the
+// value, but the write is dropped with EventLeaseLost if the key was deleted
+// or set in the meantime; the caller still receives the generated value.
+// Other callers serve the stale copy if WithLeaseStale is set and there is
+// one, otherwise they poll the key for up to the lease wait and then return
+// ErrLeaseHeld.
+//
+// Parameters:
+//   - ctx: Context bounding how long this caller waits for the shared result.
+//   - key: Cache key to check and store the value.
+//   - ttl: Time-to-live duration for the cached value.
+//   - gen: Generator function to produce the value on cache miss.
+//
+// Returns:
+//   - Result[T]: The cached, stale or generated value, or a zero value on error.
+//   - error: ErrLeaseHeld when another caller still holds the lease, ErrAtomicUnsupported
+//     when the backend is not a LeaseBackend, or any error from generation or the backend.
+func (h *Handler[T]) missLease(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	gen EntryGenerator[T],
+) (Result[T], error) {
+	lb, ok := h.config.backend.(LeaseBackend)
+	if !ok {
+		return Result[T]{}, ErrAtomicUnsupported
+	}
+	return doFlight(ctx, h.core.flights, h.fullKey(key), h.config.bgRefreshTimeout, func(flightCtx context.Context) (Result[T], error) {
+		return h.leaseFill(flightCtx, lb, key, ttl, gen)
+	})
+}
+
+// leaseFill double-checks the cache, then either fills key under a new lease
+// or waits for the current holder.
+func (h *Handler[T]) leaseFill(
+	ctx context.Context,
+	lb LeaseBackend,
+	key string,
+	ttl time.Duration,
+	gen EntryGenerator[T],
+) (Result[T], error) {
+	var zero T
+	if res, err := h.get(ctx, key); err == nil {
+		return res, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return Result[T]{Value: zero}, err
+	}
+
+	lk := leaseKey(h.fullKey(key))
+	token := rand.Text()
+	acquired, err := lb.SetNX(ctx, lk, []byte(token), h.config.leaseTTL)
+	if err != nil {
+		return Result[T]{Value: zero}, &BackendError{Op: "setnx", Key: lk, Err: err}
+	}
+	if !acquired {
+		return h.awaitLease(ctx, key)
+	}
+	prev, err := h.replaceableRaw(ctx, h.fullKey(key))
+	if err != nil {
+		_ = lb.ReleaseLease(context.WithoutCancel(ctx), lk, token)
+		return Result[T]{Value: zero}, err
+	}
+	return h.fillUnderLease(ctx, lb, key, ttl, gen, token, prev)
+}
+
+// replaceableRaw returns the bytes under fullKey when they form an entry a
+// fill may overwrite: an undecodable entry under DecodeFailureMiss, which
+// lookups treat as a miss although it still exists (see keyPresent). It
+// returns nil when the fill must find the key absent.
+func (h *Handler[T]) replaceableRaw(ctx context.Context, fullKey string) ([]byte, error) {
+	if h.config.decodeFailurePolicy != DecodeFailureMiss {
+		return nil, nil
+	}
+	raw, err := h.config.backend.Get(ctx, fullKey)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &BackendError{Op: "get", Key: fullKey, Err: err}
+	}
+	var v T
+	if _, body := splitVersion(raw); json.Unmarshal(body, &v) == nil {
+		return nil, nil // Filled since the double-check, so the fill is dropped
+	}
+	return raw, nil
+}
+
+// fillUnderLease generates the value for key and writes it if the lease
+// identified by token is still valid and key still holds prev. The lease is
+// released on every path.
+func (h *Handler[T]) fillUnderLease(
+	ctx context.Context,
+	lb LeaseBackend,
+	key string,
+	ttl time.Duration,
+	gen EntryGenerator[T],
+	token string,
+	prev []byte,
+) (Result[T], error) {
+	var zero T
+	fullKey := h.fullKey(key)
+	lk := leaseKey(fullKey)
+	release := func() { _ = lb.ReleaseLease(context.WithoutCancel(ctx), lk, token) }
+
+	version := h.newVersion()
+	e, err := gen(ctx)
+	if err != nil {
+		release()
+		return Result[T]{Value: zero}, &GeneratorError{Key: fullKey, Err: err}
+	}
+	res := Result[T]{Value: e.Value, FromCache: false, CachedAt: h.now()}
+	if e.NoCache {
+		release()
+		return res, nil
+	}
+	b, err := json.Marshal(e.Value)
+	if err != nil {
+		release()
+		return Result[T]{Value: zero}, &EncodeError{Key: fullKey, Err: err}
+	}
+	if version > 0 {
+		b = withVersion(version, b)
+	}
+
+	ttl = entryTTL(e, ttl)
+	written, err := lb.FillLease(ctx, fullKey, b, h.capLifetime(h.jitterTTL(fullKey, ttl)), lk, token, prev)
+	if err != nil {
+		release()
+		return Result[T]{Value: zero}, &BackendError{Op: "set", Key: fullKey, Err: err}
+	}
+	if !written {
+		h.emit(EventLeaseLost, fullKey, nil)
+		return res, nil
+	}
+	if err = h.afterWrite(ctx, fullKey); err != nil {
+		return Result[T]{Value: zero}, err
+	}
+	if h.config.leaseStale {
+		_ = h.setToKey(ctx, h.fullKey(key+":stale"), e.Value, h.config.staleDataTTL)
+	}
+	return res, nil
+}
+
+// awaitLease serves the stale copy of key if WithLeaseStale is set and there
+// is one, otherwise polls the key until the lease holder fills it or the
+// lease wait elapses. Both are measured on the handler's clock.
+func (h *Handler[T]) awaitLease(ctx context.Context, key string) (Result[T], error) {
+	var zero T
+	if h.config.leaseStale {
+		if v, err := h.getFromKey(ctx, h.fullKey(key+":stale")); err == nil {
+			return Result[T]{Value: v, FromCache: true, CachedAt: h.now()}, nil
+		}
+	}
+
+	timeout := h.after(h.config.leaseWait)
+	for {
+		select {
+		case <-ctx.Done():
+			return Result[T]{Value: zero}, ctx.Err()
+		case <-timeout:
+			return Result[T]{Value: zero}, ErrLeaseHeld
+		case <-h.after(leasePollInterval):
+			if res, err := h.get(ctx, key); err == nil {
+				return res, nil
+			} else if !errors.Is(err, ErrNotFound) {
+				return Result[T]{Value: zero}, err
+			}
+		}
+	}
+}
+
+// fillLeaseScript writes KEYS[1] = ARGV[1] with a PX of ARGV[3] (none when
+// <= 0) if KEYS[2] holds the lease token ARGV[2] and KEYS[1] is absent, or,
+// when ARGV[4] is "1", still holds ARGV[5]. A matching lease is deleted
+// either way.
+var fillLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[2]) ~= ARGV[2] then
+  return 0
+end
+redis.call('DEL', KEYS[2])
+local cur = redis.call('GET', KEYS[1])
+if ARGV[4] == '1' then
+  if cur ~= ARGV[5] then
+    return 0
+  end
+elseif cur then
+  return 0
+end
+local px = tonumber(ARGV[3])
+if px > 0 then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', px)
+else
+  redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// releaseLeaseScript deletes KEYS[1] if it holds the lease token ARGV[1].
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+func (b *RedisBackend) FillLease(
+	ctx context.Context,
+	key string,
+	value []byte,
+	ttl time.Duration,
+	leaseKey, token string,
+	prev []byte,
+) (bool, error) {
+	replace := "0"
+	if prev != nil {
+		replace = "1"
+	}
+	n, err := fillLeaseScript.Run(ctx, b.rdb, []string{key, leaseKey},
+		value, token, ttl.Milliseconds(), replace, prev).Int()
+	return n == 1, err
+}
+
+func (b *RedisBackend) ReleaseLease(ctx context.Context, leaseKey, token string) error {
+	return releaseLeaseScript.Run(ctx, b.rdb, []string{leaseKey}, token).Err()
+}
+
+func (m *MemoryBackend) FillLease(
+	_ context.Context,
+	key string,
+	value []byte,
+	ttl time.Duration,
+	leaseKey, token string,
+	prev []byte,
+) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.live(leaseKey); !ok || string(e.value) != token {
+		return false, nil
+	}
+	delete(m.entries, leaseKey)
+	if e, ok := m.live(key); ok != (prev != nil) || ok && !bytes.Equal(e.value, prev) {
+		return false, nil
+	}
+	m.store(key, value, ttl)
+	return true, nil
+}
+
+func (m *MemoryBackend) ReleaseLease(_ context.Context, leaseKey, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.live(leaseKey); ok && string(e.value) == token {
+		delete(m.entries, leaseKey)
+	}
+	return nil
+}
+
+var (
+	_ LeaseBackend = (*RedisBackend)(nil)
+	_ LeaseBackend = (*MemoryBackend)(nil)
+)
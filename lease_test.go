@@ -0,0 +1,224 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestMissFillLease tests lease-coordinated fills across handlers sharing a backend.
+func TestMissFillLease(t *testing.T) {
+	ctx := context.Background()
+	leased := cache.WithMissFillPolicy(cache.MissFillLease)
+
+	// blockingFill starts a fill on h that blocks until the returned release
+	// func is called, and waits for its generator to start.
+	blockingFill := func(h *cache.Handler[string], value string) (release func(), done <-chan cache.Result[string]) {
+		started := make(chan struct{})
+		unblock := make(chan struct{})
+		out := make(chan cache.Result[string], 1)
+		go func() {
+			res, _ := h.GetOrRefresh(ctx, "key", func(context.Context) (string, error) {
+				close(started)
+				<-unblock
+				return value, nil
+			})
+			out <- res
+		}()
+		<-started
+		return func() { close(unblock) }, out
+	}
+
+	t.Run("Fills and releases the lease", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		h, _ := cache.NewWithBackend[string](b, leased)
+		res, err := h.GetOrRefresh(ctx, "key", func(context.Context) (string, error) { return "v", nil })
+		if err != nil || res.Value != "v" {
+			t.Fatalf("Expected v, got %q, %v", res.Value, err)
+		}
+		if ok, _ := b.Exists(ctx, "{key}:lease"); ok {
+			t.Error("Expected the lease to be released")
+		}
+		if r, _ := h.Get(ctx, "key"); r.Value != "v" {
+			t.Errorf("Expected the value to be cached, got %q", r.Value)
+		}
+	})
+
+	t.Run("Other pods get try again", func(t *testing.T) {
+		podA, _ := cachetest.NewHandler[string](leased)
+		podB, _ := cache.NewWithBackend[string](podA.Backend, leased,
+			cache.WithClock(podA.Clock), cache.WithLease(time.Minute, time.Second))
+		release, done := blockingFill(podA.Handler, "a")
+		defer func() { release(); <-done }()
+
+		var calls atomic.Int32
+		errc := make(chan error, 1)
+		go func() {
+			_, err := podB.GetOrRefresh(ctx, "key", func(context.Context) (string, error) {
+				calls.Add(1)
+				return "b", nil
+			})
+			errc <- err
+		}()
+		podA.Clock.BlockUntil(2) // The lease wait and the first poll
+		podA.Clock.Advance(time.Second)
+		if err := <-errc; !errors.Is(err, cache.ErrLeaseHeld) || !errors.Is(err, cache.ErrCacheMiss) {
+			t.Errorf("Expected ErrLeaseHeld, got %v", err)
+		}
+		if calls.Load() != 0 {
+			t.Error("Expected the generator not to run without the lease")
+		}
+	})
+
+	t.Run("Other pods wait for the holder", func(t *testing.T) {
+		podA, _ := cachetest.NewHandler[string](leased)
+		podB, _ := cache.NewWithBackend[string](podA.Backend, leased,
+			cache.WithClock(podA.Clock), cache.WithLease(time.Minute, 5*time.Second))
+		release, done := blockingFill(podA.Handler, "a")
+
+		type result struct {
+			res cache.Result[string]
+			err error
+		}
+		out := make(chan result, 1)
+		go func() {
+			res, err := podB.GetOrRefresh(ctx, "key", func(context.Context) (string, error) { return "b", nil })
+			out <- result{res, err}
+		}()
+		podA.Clock.BlockUntil(2)
+		release()
+		<-done
+		podA.Clock.Advance(time.Second) // Fires the next poll, not the lease wait
+
+		if r := <-out; r.err != nil || r.res.Value != "a" || !r.res.FromCache {
+			t.Errorf("Expected the holder's value from cache, got %+v, %v", r.res, r.err)
+		}
+	})
+
+	t.Run("Other pods serve stale", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		podA, _ := cache.NewWithBackend[string](b, leased, cache.WithLeaseStale())
+		podB, _ := cache.NewWithBackend[string](b, leased, cache.WithLeaseStale())
+		_ = b.Set(ctx, "key:stale", []byte(`"old"`), time.Hour)
+		release, done := blockingFill(podA, "a")
+		defer func() { release(); <-done }()
+
+		res, err := podB.GetOrRefresh(ctx, "key", func(context.Context) (string, error) { return "b", nil })
+		if err != nil || res.Value != "old" {
+			t.Errorf("Expected the stale copy, got %q, %v", res.Value, err)
+		}
+	})
+
+	t.Run("Stale copies are opt-in", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		h, _ := cache.NewWithBackend[string](b, leased)
+		_, _ = h.GetOrRefresh(ctx, "key", func(context.Context) (string, error) { return "v", nil })
+		if ok, _ := b.Exists(ctx, "key:stale"); ok {
+			t.Error("Expected no stale copy without WithLeaseStale")
+		}
+
+		h, _ = cache.NewWithBackend[string](b, leased, cache.WithLeaseStale())
+		_, _ = h.GetOrRefresh(ctx, "other", func(context.Context) (string, error) { return "v", nil })
+		if ok, _ := b.Exists(ctx, "other:stale"); !ok {
+			t.Error("Expected a stale copy with WithLeaseStale")
+		}
+
+		cfg := cache.DefaultConfig()
+		cfg.LeaseTTL = 0
+		if err := cfg.Validate(); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected a zero LeaseTTL to be rejected under any policy, got %v", err)
+		}
+
+		cfg = cache.DefaultConfig()
+		cfg.LeaseStale = true
+		cfg.StaleDataTTL = 0
+		if err := cfg.Validate(); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected LeaseStale without a stale TTL to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("Replaces a poisoned entry", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		h, _ := cache.NewWithBackend[string](b, leased, cache.WithDecodeFailurePolicy(cache.DecodeFailureMiss))
+		_ = b.Set(ctx, "key", []byte("not json"), time.Hour)
+
+		res, err := h.GetOrRefresh(ctx, "key", func(context.Context) (string, error) { return "v", nil })
+		if err != nil || res.Value != "v" {
+			t.Fatalf("Expected v, got %q, %v", res.Value, err)
+		}
+		if r, err := h.Get(ctx, "key"); err != nil || r.Value != "v" {
+			t.Errorf("Expected the fill to replace the poisoned entry, got %q, %v", r.Value, err)
+		}
+		if ok, _ := b.Exists(ctx, "{key}:lease"); ok {
+			t.Error("Expected the lease to be released")
+		}
+	})
+
+	t.Run("Delete during the fill drops it", func(t *testing.T) {
+		lost := 0
+		b := cache.NewMemoryBackend()
+		podA, _ := cache.NewWithBackend[string](b, leased,
+			cache.WithObserver(func(e cache.Event) {
+				if e.Kind == cache.EventLeaseLost {
+					lost++
+				}
+			}),
+		)
+		podB, _ := cache.NewWithBackend[string](b)
+		release, done := blockingFill(podA, "computed before the delete")
+		_ = podB.Delete(ctx, "key")
+		release()
+
+		if res := <-done; res.Value != "computed before the delete" {
+			t.Errorf("Expected the caller to still get its value, got %q", res.Value)
+		}
+		if _, err := podB.Get(ctx, "key"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected the fill to be dropped, got %v", err)
+		}
+		if lost != 1 {
+			t.Errorf("Expected one EventLeaseLost, got %d", lost)
+		}
+	})
+
+	t.Run("Set during the fill wins", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		podA, _ := cache.NewWithBackend[string](b, leased)
+		podB, _ := cache.NewWithBackend[string](b)
+		release, done := blockingFill(podA, "old")
+		_ = podB.Set(ctx, "key", "new")
+		release()
+		<-done
+
+		if r, _ := podB.Get(ctx, "key"); r.Value != "new" {
+			t.Errorf("Expected the Set to survive, got %q", r.Value)
+		}
+		if ok, _ := b.Exists(ctx, "{key}:lease"); ok {
+			t.Error("Expected the lease to be released")
+		}
+	})
+
+	t.Run("Generator errors release the lease", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		h, _ := cache.NewWithBackend[string](b, leased)
+		boom := errors.New("boom")
+		if _, err := h.GetOrRefresh(ctx, "key", func(context.Context) (string, error) { return "", boom }); !errors.Is(err, boom) {
+			t.Fatalf("Expected boom, got %v", err)
+		}
+		if res, err := h.GetOrRefresh(ctx, "key", func(context.Context) (string, error) { return "v", nil }); err != nil || res.Value != "v" {
+			t.Errorf("Expected the next caller to fill, got %q, %v", res.Value, err)
+		}
+	})
+
+	t.Run("Requires a lease backend", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[string](plainBackend{cache.NewMemoryBackend()}, leased)
+		_, err := h.GetOrRefresh(ctx, "key", func(context.Context) (string, error) { return "v", nil })
+		if !errors.Is(err, cache.ErrAtomicUnsupported) {
+			t.Errorf("Expected ErrAtomicUnsupported, got %v", err)
+		}
+	})
+}
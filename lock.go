@@ -1,6 +1,9 @@
 package cache
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 // ---------------------------
 // In-memory keyed mutex
@@ -32,6 +35,22 @@ func (km *KeyedMutex) Lock(key string) func() {
 	return func() { <-ch }
 }
 
+// LockContext acquires the lock for key, giving up when ctx is done. On
+// cancellation it returns ctx.Err() and a no-op unlock; the caller never holds
+// the lock afterwards.
+func (km *KeyedMutex) LockContext(ctx context.Context, key string) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return func() {}, err
+	}
+	ch := km.ch(key)
+	select {
+	case ch <- struct{}{}:
+		return func() { <-ch }, nil
+	case <-ctx.Done():
+		return func() {}, ctx.Err()
+	}
+}
+
 func (km *KeyedMutex) TryLock(key string) (func(), bool) {
 	ch := km.ch(key)
 	select {
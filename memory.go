@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBackend is an in-process Backend with TTL expiry, intended for unit
+// tests and local development. Expired entries are dropped lazily when touched
+// and swept periodically on writes.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	writes  int
+	clock   Clock
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // Zero means no expiry
+}
+
+var _ AtomicBackend = (*MemoryBackend)(nil)
+
+// memorySweepEvery is the number of writes between sweeps of expired entries.
+const memorySweepEvery = 1024
+
+// NewMemoryBackend returns an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return NewMemoryBackendWithClock(systemClock{})
+}
+
+// NewMemoryBackendWithClock returns an empty MemoryBackend whose TTL expiry
+// follows c. Pass the same clock to WithClock so entries expire in step with
+// the handler's view of time.
+func NewMemoryBackendWithClock(c Clock) *MemoryBackend {
+	if c == nil {
+		c = systemClock{}
+	}
+	return &MemoryBackend{entries: make(map[string]memoryEntry), clock: c}
+}
+
+func (m *MemoryBackend) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.live(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(e.value), nil
+}
+
+func (m *MemoryBackend) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store(key, value, ttl)
+	return nil
+}
+
+func (m *MemoryBackend) TTL(_ context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.live(key)
+	if !ok {
+		return 0, ErrNotFound
+	}
+	if e.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return e.expiresAt.Sub(m.clock.Now()), nil
+}
+
+func (m *MemoryBackend) Exists(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.live(key)
+	return ok, nil
+}
+
+func (m *MemoryBackend) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range keys {
+		delete(m.entries, k)
+	}
+	return nil
+}
+
+func (m *MemoryBackend) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.live(key); ok {
+		return false, nil
+	}
+	m.store(key, value, ttl)
+	return true, nil
+}
+
+func (m *MemoryBackend) CompareAndSwap(
+	_ context.Context,
+	key string,
+	prev, next []byte,
+	ttl time.Duration,
+) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.live(key)
+	if ok != (prev != nil) || (ok && !bytes.Equal(e.value, prev)) {
+		return false, nil
+	}
+	m.store(key, next, ttl)
+	return true, nil
+}
+
+// Flush removes every entry.
+func (m *MemoryBackend) Flush() {
+	m.mu.Lock()
+	m.entries = make(map[string]memoryEntry)
+	m.mu.Unlock()
+}
+
+// Keys returns the keys of all live entries, in no particular order.
+func (m *MemoryBackend) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.entries))
+	for k := range m.entries {
+		if _, ok := m.live(k); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// live returns the entry for key if it exists and has not expired, dropping it
+// otherwise. m.mu must be held.
+func (m *MemoryBackend) live(key string) (memoryEntry, bool) {
+	e, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !e.expiresAt.IsZero() && !m.clock.Now().Before(e.expiresAt) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return e, true
+}
+
+// store writes an entry and periodically sweeps expired ones. m.mu must be held.
+func (m *MemoryBackend) store(key string, value []byte, ttl time.Duration) {
+	e := memoryEntry{value: bytes.Clone(value)}
+	if ttl > 0 {
+		e.expiresAt = m.clock.Now().Add(ttl)
+	}
+	m.entries[key] = e
+
+	m.writes++
+	if m.writes%memorySweepEvery == 0 {
+		for k := range m.entries {
+			m.live(k)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// EventKind identifies what happened inside a Handler.
+type EventKind int
+
+const (
+	// EventDecodeFailure is emitted whenever a stored value cannot be decoded
+	// into T, regardless of the configured DecodeFailurePolicy. Counting these
+	// is the simplest way to spot schema mismatches during a rolling deploy.
+	EventDecodeFailure EventKind = iota + 1
+
+	// EventSchemaMigrated is emitted when an entry written under an older schema
+	// version is migrated and rewritten under the current version.
+	EventSchemaMigrated
+
+	// EventHit is emitted when Get or GetOrRefresh finds the key in the cache.
+	EventHit
+
+	// EventMiss is emitted when Get or GetOrRefresh does not find the key.
+	EventMiss
+
+	// EventGenerate is emitted after GetOrRefresh invokes the generator, on the
+	// miss path or in the background. Err carries the generator error, if any.
+	EventGenerate
+
+	// EventBackgroundRefresh is emitted when a background refresh (hit refresh or
+	// stale rewrite) has run the generator. Err carries the generator error, if any.
+	EventBackgroundRefresh
+
+	// EventConfigChanged is emitted after Reconfigure swaps in a new
+	// configuration. Key is empty.
+	EventConfigChanged
+
+	// EventConfigReloadFailed is emitted when WatchConfig cannot read or apply a
+	// new configuration. Err carries the reason; Key is empty.
+	EventConfigReloadFailed
+
+	// EventWarm is emitted when Warm fills a key. Err carries the generator or
+	// write error when the fill failed; fresh keys are skipped silently.
+	EventWarm
+
+	// EventWarmCompleted is emitted at the end of every Warm pass, including
+	// each Warmer pass. Err joins the per-key failures; Key is empty.
+	EventWarmCompleted
+
+	// EventHotKeyPromoted is emitted when a hot key gets an in-process copy
+	// (see WithHotKeyPromotion).
+	EventHotKeyPromoted
+
+	// EventScheduleIdle is emitted when a Scheduler deregisters a key that was
+	// not read within its idle timeout.
+	EventScheduleIdle
+
+	// EventPersistFailed is emitted when WriteBehind gives up persisting a
+	// write and invalidates the cached value. Err carries a *PersistError.
+	EventPersistFailed
+
+	// EventUpdateConflict is emitted each time Update loses a race against
+	// another writer and retries.
+	EventUpdateConflict
+
+	// EventStaleWriteDropped is emitted when a versioned write is skipped
+	// because the cache already holds a newer entry (see WithVersionedWrites).
+	EventStaleWriteDropped
+
+	// EventLeaseLost is emitted when a MissFillLease fill is not written
+	// because the key was deleted or set, or the lease expired, during generation.
+	EventLeaseLost
+)
+
+// String returns a human-readable name for the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventDecodeFailure:
+		return "decode_failure"
+	case EventSchemaMigrated:
+		return "schema_migrated"
+	case EventHit:
+		return "hit"
+	case EventMiss:
+		return "miss"
+	case EventGenerate:
+		return "generate"
+	case EventBackgroundRefresh:
+		return "background_refresh"
+	case EventConfigChanged:
+		return "config_changed"
+	case EventConfigReloadFailed:
+		return "config_reload_failed"
+	case EventWarm:
+		return "warm"
+	case EventWarmCompleted:
+		return "warm_completed"
+	case EventHotKeyPromoted:
+		return "hot_key_promoted"
+	case EventScheduleIdle:
+		return "schedule_idle"
+	case EventPersistFailed:
+		return "persist_failed"
+	case EventUpdateConflict:
+		return "update_conflict"
+	case EventStaleWriteDropped:
+		return "stale_write_dropped"
+	case EventLeaseLost:
+		return "lease_lost"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a notable occurrence reported to an Observer.
+type Event struct {
+	Kind EventKind
+	Key  string    // Full cache key (including prefix); empty for handler-level events
+	Err  error     // Error associated with the event, if any
+	Time time.Time // When the event was emitted
+}
+
+// Observer receives handler events. Observers are called synchronously on the
+// goroutine that produced the event and must not block.
+type Observer func(Event)
+
+// WithObserver registers an Observer for handler events. It may be passed more
+// than once; observers are called in registration order.
+func WithObserver(o Observer) Option {
+	return func(c *handlerConfig) {
+		if o != nil {
+			c.observers = append(c.observers, o)
+		}
+	}
+}
+
+// emit delivers an event to every registered observer.
+func (h *Handler[T]) emit(kind EventKind, fullKey string, err error) {
+	if len(h.config.observers) == 0 {
+		return
+	}
+	e := Event{Kind: kind, Key: fullKey, Err: err, Time: h.now()}
+	for _, o := range h.config.observers {
+		o(e)
+	}
+}
+
+// emitLookup reports the outcome of a public cache lookup as EventHit or EventMiss.
+// Lookups that failed for other reasons are reported through their own events.
+func (h *Handler[T]) emitLookup(fullKey string, err error) {
+	switch {
+	case err == nil:
+		h.emit(EventHit, fullKey, nil)
+	case errors.Is(err, ErrNotFound):
+		h.emit(EventMiss, fullKey, nil)
+	}
+}
+
+// observeGenerator wraps gen so that every invocation emits EventGenerate.
+// It returns gen unchanged when no observers are registered.
+func (h *Handler[T]) observeGenerator(fullKey string, gen EntryGenerator[T]) EntryGenerator[T] {
+	if len(h.config.observers) == 0 {
+		return gen
+	}
+	return func(ctx context.Context) (Entry[T], error) {
+		e, err := gen(ctx)
+		h.emit(EventGenerate, fullKey, err)
+		return e, err
+	}
+}
@@ -1,5 +1,13 @@
 package cache
 
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // MissFillPolicy controls what happens when data is not found in the cache.
 // It is one of three independent cache behaviour axes; see also HitRefreshPolicy
 // and ErrorPolicy.
@@ -23,8 +31,8 @@ const (
 
 	// MissFillStaleOrSync returns stale (expired) data immediately if available,
 	// triggering a background refresh. Falls back to MissFillSync when no stale
-	// data exists. Requires WithStaleDataTTL on the handler; without it the stale
-	// lookup will always miss and this behaves identically to MissFillSync.
+	// data exists. Stale copies are kept for StaleDataTTL (24h by default);
+	// constructors reject an explicit zero with ErrInvalidConfig.
 	MissFillStaleOrSync
 
 	// MissFillFailFast returns ErrCacheMiss immediately without calling the
@@ -36,6 +44,24 @@ const (
 	// block until the lock is released or WithCooperativeTimeout elapses, at which
 	// point they fall back to direct generation without caching.
 	MissFillCooperative
+
+	// MissFillCoalesce shares one in-flight generation among all concurrent
+	// callers for the same key in this process (singleflight). The generator runs
+	// once, its result is written to the cache, and every waiter receives the same
+	// value or the same error. A waiter whose context is cancelled stops waiting
+	// without affecting the others; the generation is cancelled only when no
+	// waiters remain. Coordination is in-process only.
+	MissFillCoalesce
+
+	// MissFillLease coordinates fills across pods with a memcache-style lease:
+	// the first caller to miss stores a lease token next to the key, generates
+	// the value and writes it only if the token is still valid, so a Delete or
+	// Set during generation is never overwritten. Other callers poll for the
+	// value and give up with ErrLeaseHeld ("try again") after the lease wait;
+	// with WithLeaseStale they serve the stale copy instead if one exists.
+	// Callers in one process share a single attempt. Configure with WithLease;
+	// requires a LeaseBackend.
+	MissFillLease
 )
 
 // HitRefreshPolicy controls proactive background refresh behaviour when the
@@ -85,3 +111,126 @@ const (
 	// Use for non-critical data where partial availability is acceptable.
 	ErrorPolicyZeroValue
 )
+
+// DecodeFailurePolicy controls what happens when a cached value exists but can
+// no longer be decoded into T, typically after a struct change ships while old
+// entries are still live. Every decode failure emits EventDecodeFailure.
+type DecodeFailurePolicy int
+
+const (
+	// DecodeFailureSurface returns the *DecodeError to the caller. This is the
+	// default and the zero value.
+	DecodeFailureSurface DecodeFailurePolicy = iota
+
+	// DecodeFailureMiss treats the undecodable entry as a miss. GetOrRefresh
+	// regenerates the value and overwrites the poisoned entry through the
+	// active MissFillPolicy.
+	DecodeFailureMiss
+
+	// DecodeFailureDelete deletes the undecodable entry from Redis, then treats
+	// the lookup as a miss.
+	DecodeFailureDelete
+)
+
+// Policy names used by String, MarshalText and UnmarshalText, and therefore by
+// configuration files. Indexed by the policy value.
+var (
+	missFillPolicyNames      = []string{"default", "sync", "async", "stale_or_sync", "fail_fast", "cooperative", "coalesce", "lease"}
+	hitRefreshPolicyNames    = []string{"default", "ahead", "probabilistic", "older_than", "none"}
+	errorPolicyNames         = []string{"surface", "zero_value"}
+	decodeFailurePolicyNames = []string{"surface", "miss", "delete"}
+)
+
+func (p MissFillPolicy) String() string { return policyName(missFillPolicyNames, int(p)) }
+
+func (p HitRefreshPolicy) String() string { return policyName(hitRefreshPolicyNames, int(p)) }
+
+func (p ErrorPolicy) String() string { return policyName(errorPolicyNames, int(p)) }
+
+func (p DecodeFailurePolicy) String() string { return policyName(decodeFailurePolicyNames, int(p)) }
+
+func (p MissFillPolicy) MarshalText() ([]byte, error) { return []byte(p.String()), nil }
+
+func (p HitRefreshPolicy) MarshalText() ([]byte, error) { return []byte(p.String()), nil }
+
+func (p ErrorPolicy) MarshalText() ([]byte, error) { return []byte(p.String()), nil }
+
+func (p DecodeFailurePolicy) MarshalText() ([]byte, error) { return []byte(p.String()), nil }
+
+// UnmarshalText parses a policy name such as "stale_or_sync". Matching is
+// case-insensitive and accepts '-' in place of '_'. The policy's number is
+// accepted too, as configurations written before policies had names use it.
+func (p *MissFillPolicy) UnmarshalText(text []byte) error {
+	v, err := parsePolicyName("MissFillPolicy", missFillPolicyNames, text)
+	*p = MissFillPolicy(v)
+	return err
+}
+
+// UnmarshalText parses a policy name such as "older_than".
+func (p *HitRefreshPolicy) UnmarshalText(text []byte) error {
+	v, err := parsePolicyName("HitRefreshPolicy", hitRefreshPolicyNames, text)
+	*p = HitRefreshPolicy(v)
+	return err
+}
+
+// UnmarshalText parses a policy name such as "zero_value".
+func (p *ErrorPolicy) UnmarshalText(text []byte) error {
+	v, err := parsePolicyName("ErrorPolicy", errorPolicyNames, text)
+	*p = ErrorPolicy(v)
+	return err
+}
+
+// UnmarshalText parses a policy name such as "delete".
+func (p *DecodeFailurePolicy) UnmarshalText(text []byte) error {
+	v, err := parsePolicyName("DecodeFailurePolicy", decodeFailurePolicyNames, text)
+	*p = DecodeFailurePolicy(v)
+	return err
+}
+
+// UnmarshalJSON accepts a policy name or number; see UnmarshalText.
+func (p *MissFillPolicy) UnmarshalJSON(data []byte) error { return unmarshalPolicyJSON(data, p) }
+
+// UnmarshalJSON accepts a policy name or number.
+func (p *HitRefreshPolicy) UnmarshalJSON(data []byte) error { return unmarshalPolicyJSON(data, p) }
+
+// UnmarshalJSON accepts a policy name or number.
+func (p *ErrorPolicy) UnmarshalJSON(data []byte) error { return unmarshalPolicyJSON(data, p) }
+
+// UnmarshalJSON accepts a policy name or number.
+func (p *DecodeFailurePolicy) UnmarshalJSON(data []byte) error { return unmarshalPolicyJSON(data, p) }
+
+// unmarshalPolicyJSON decodes a JSON string or number into p. encoding/json
+// hands only strings to UnmarshalText, so numbers are passed through here.
+func unmarshalPolicyJSON(data []byte, p encoding.TextUnmarshaler) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] != '"' {
+		return p.UnmarshalText(data)
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return p.UnmarshalText([]byte(s))
+}
+
+func policyName(names []string, v int) string {
+	if v < 0 || v >= len(names) {
+		return strconv.Itoa(v)
+	}
+	return names[v]
+}
+
+func parsePolicyName(kind string, names []string, text []byte) (int, error) {
+	name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(string(text))), "-", "_")
+	if v, err := strconv.Atoi(name); err == nil && v >= 0 && v < len(names) {
+		return v, nil
+	}
+	for i, n := range names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown %s %q (want one of %s)", ErrInvalidConfig, kind, text, strings.Join(names, ", "))
+}
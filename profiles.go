@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/yaml.v3"
+)
+
+// Profiles is a set of named configurations loaded from a file, letting
+// operators tune TTLs and policies without code changes. A file has an
+// optional "defaults" section applied to every profile and a "profiles" map:
+//
+//	defaults:
+//	  ttl: 5m
+//	profiles:
+//	  users:
+//	    prefix: user
+//	    miss_fill_policy: coalesce
+//	    hit_refresh_policy: ahead
+//	    refresh_ahead_threshold: 0.2
+//	  reports:
+//	    prefix: report
+//	    ttl: 1h
+//	    miss_fill_policy: stale_or_sync
+//	    stale_data_ttl: 24h
+//
+// Durations use time.ParseDuration syntax and policies are referenced by name
+// (see MissFillPolicy.String and friends) or, as in older files, by number.
+// Unset fields fall back to the defaults section, then to DefaultConfig.
+// Every profile is checked with Config.Validate when the file is loaded.
+type Profiles struct {
+	configs map[string]Config
+}
+
+// profileFile is the on-disk layout shared by the YAML, JSON and TOML formats.
+type profileFile struct {
+	Defaults profileSpec            `json:"defaults" yaml:"defaults" toml:"defaults"`
+	Profiles map[string]profileSpec `json:"profiles" yaml:"profiles" toml:"profiles"`
+}
+
+// profileSpec holds the optional fields of one profile; nil means "inherit".
+type profileSpec struct {
+	Prefix                   *string              `json:"prefix"                     yaml:"prefix"                     toml:"prefix"`
+	TTL                      *fileDuration        `json:"ttl"                        yaml:"ttl"                        toml:"ttl"`
+	BackgroundRefreshTimeout *fileDuration        `json:"background_refresh_timeout" yaml:"background_refresh_timeout" toml:"background_refresh_timeout"`
+	RefreshCooldown          *fileDuration        `json:"refresh_cooldown"           yaml:"refresh_cooldown"           toml:"refresh_cooldown"`
+	MissFillPolicy           *MissFillPolicy      `json:"miss_fill_policy"           yaml:"miss_fill_policy"           toml:"miss_fill_policy"`
+	HitRefreshPolicy         *HitRefreshPolicy    `json:"hit_refresh_policy"         yaml:"hit_refresh_policy"         toml:"hit_refresh_policy"`
+	ErrorPolicy              *ErrorPolicy         `json:"error_policy"               yaml:"error_policy"               toml:"error_policy"`
+	DecodeFailurePolicy      *DecodeFailurePolicy `json:"decode_failure_policy"      yaml:"decode_failure_policy"      toml:"decode_failure_policy"`
+	StaleDataTTL             *fileDuration        `json:"stale_data_ttl"             yaml:"stale_data_ttl"             toml:"stale_data_ttl"`
+	RefreshAheadThreshold    *float64             `json:"refresh_ahead_threshold"    yaml:"refresh_ahead_threshold"    toml:"refresh_ahead_threshold"`
+	ProbabilisticBeta        *float64             `json:"probabilistic_beta"         yaml:"probabilistic_beta"         toml:"probabilistic_beta"`
+	RefreshOlderThanAge      *fileDuration        `json:"refresh_older_than_age"     yaml:"refresh_older_than_age"     toml:"refresh_older_than_age"`
+	CooperativeTimeout       *fileDuration        `json:"cooperative_timeout"        yaml:"cooperative_timeout"        toml:"cooperative_timeout"`
+	MissDeduplicationWindow  *fileDuration        `json:"miss_deduplication_window"  yaml:"miss_deduplication_window"  toml:"miss_deduplication_window"`
+	TTLJitter                *float64             `json:"ttl_jitter"                 yaml:"ttl_jitter"                 toml:"ttl_jitter"`
+	TTLJitterMin             *fileDuration        `json:"ttl_jitter_min"             yaml:"ttl_jitter_min"             toml:"ttl_jitter_min"`
+	TTLJitterMax             *fileDuration        `json:"ttl_jitter_max"             yaml:"ttl_jitter_max"             toml:"ttl_jitter_max"`
+	DeterministicJitter      *bool                `json:"deterministic_jitter"       yaml:"deterministic_jitter"       toml:"deterministic_jitter"`
+	SlidingExpiration        *bool                `json:"sliding_expiration"         yaml:"sliding_expiration"         toml:"sliding_expiration"`
+	MaxLifetime              *fileDuration        `json:"max_lifetime"               yaml:"max_lifetime"               toml:"max_lifetime"`
+	HotKeyWindow             *fileDuration        `json:"hot_key_window"             yaml:"hot_key_window"             toml:"hot_key_window"`
+	HotKeyTopK               *int                 `json:"hot_key_top_k"              yaml:"hot_key_top_k"              toml:"hot_key_top_k"`
+	HotKeySampleRate         *float64             `json:"hot_key_sample_rate"        yaml:"hot_key_sample_rate"        toml:"hot_key_sample_rate"`
+	HotKeyThreshold          *float64             `json:"hot_key_threshold"          yaml:"hot_key_threshold"          toml:"hot_key_threshold"`
+	HotKeyLocalTTL           *fileDuration        `json:"hot_key_local_ttl"          yaml:"hot_key_local_ttl"          toml:"hot_key_local_ttl"`
+	VersionedWrites          *bool                `json:"versioned_writes"           yaml:"versioned_writes"           toml:"versioned_writes"`
+	LeaseTTL                 *fileDuration        `json:"lease_ttl"                  yaml:"lease_ttl"                  toml:"lease_ttl"`
+	LeaseWait                *fileDuration        `json:"lease_wait"                 yaml:"lease_wait"                 toml:"lease_wait"`
+	LeaseStale               *bool                `json:"lease_stale"                yaml:"lease_stale"                toml:"lease_stale"`
+}
+
+// fileDuration decodes "90s"-style strings in every supported format.
+type fileDuration time.Duration
+
+func (d *fileDuration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = fileDuration(v)
+	return nil
+}
+
+// applyTo overrides the fields of cfg that are set in s.
+func (s profileSpec) applyTo(cfg *Config) {
+	setIf(&cfg.Prefix, s.Prefix)
+	setDurationIf(&cfg.DefaultTTL, s.TTL)
+	setDurationIf(&cfg.BackgroundRefreshTimeout, s.BackgroundRefreshTimeout)
+	setDurationIf(&cfg.RefreshCooldown, s.RefreshCooldown)
+	setIf(&cfg.MissFillPolicy, s.MissFillPolicy)
+	setIf(&cfg.HitRefreshPolicy, s.HitRefreshPolicy)
+	setIf(&cfg.ErrorPolicy, s.ErrorPolicy)
+	setIf(&cfg.DecodeFailurePolicy, s.DecodeFailurePolicy)
+	setDurationIf(&cfg.StaleDataTTL, s.StaleDataTTL)
+	setIf(&cfg.RefreshAheadThreshold, s.RefreshAheadThreshold)
+	setIf(&cfg.ProbabilisticBeta, s.ProbabilisticBeta)
+	setDurationIf(&cfg.RefreshOlderThanAge, s.RefreshOlderThanAge)
+	setDurationIf(&cfg.CooperativeTimeout, s.CooperativeTimeout)
+	setDurationIf(&cfg.MissDeduplicationWindow, s.MissDeduplicationWindow)
+	setIf(&cfg.TTLJitter, s.TTLJitter)
+	setDurationIf(&cfg.TTLJitterMin, s.TTLJitterMin)
+	setDurationIf(&cfg.TTLJitterMax, s.TTLJitterMax)
+	setIf(&cfg.DeterministicJitter, s.DeterministicJitter)
+	setIf(&cfg.SlidingExpiration, s.SlidingExpiration)
+	setDurationIf(&cfg.MaxLifetime, s.MaxLifetime)
+	setDurationIf(&cfg.HotKeyWindow, s.HotKeyWindow)
+	setIf(&cfg.HotKeyTopK, s.HotKeyTopK)
+	setIf(&cfg.HotKeySampleRate, s.HotKeySampleRate)
+	setIf(&cfg.HotKeyThreshold, s.HotKeyThreshold)
+	setDurationIf(&cfg.HotKeyLocalTTL, s.HotKeyLocalTTL)
+	setIf(&cfg.VersionedWrites, s.VersionedWrites)
+	setDurationIf(&cfg.LeaseTTL, s.LeaseTTL)
+	setDurationIf(&cfg.LeaseWait, s.LeaseWait)
+	setIf(&cfg.LeaseStale, s.LeaseStale)
+}
+
+func setIf[V any](dst *V, src *V) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setDurationIf(dst *time.Duration, src *fileDuration) {
+	if src != nil {
+		*dst = time.Duration(*src)
+	}
+}
+
+// LoadProfiles reads a profile file. The format is chosen by extension:
+// .yaml/.yml, .json or .toml.
+//
+// Parameters:
+//   - path: Path of the profile file.
+//
+// Returns:
+//   - *Profiles: The validated profiles.
+//   - error: Any read, parse or validation error; validation errors match ErrInvalidConfig.
+func LoadProfiles(path string) (*Profiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cache: load profiles: %w", err)
+	}
+	p, err := ParseProfiles(data, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ParseProfiles parses profile data in the given format ("yaml", "yml",
+// "json" or "toml"). Unknown keys are rejected so that typos do not silently
+// fall back to defaults.
+func ParseProfiles(data []byte, format string) (*Profiles, error) {
+	var f profileFile
+	if err := decodeProfileFile(data, strings.ToLower(format), &f); err != nil {
+		return nil, err
+	}
+
+	p := &Profiles{configs: make(map[string]Config, len(f.Profiles))}
+	for name, spec := range f.Profiles {
+		cfg := DefaultConfig()
+		f.Defaults.applyTo(&cfg)
+		spec.applyTo(&cfg)
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+		p.configs[name] = cfg
+	}
+	return p, nil
+}
+
+func decodeProfileFile(data []byte, format string, f *profileFile) error {
+	switch format {
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(f); err != nil {
+			return fmt.Errorf("cache: parse yaml profiles: %w", err)
+		}
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(f); err != nil {
+			return fmt.Errorf("cache: parse json profiles: %w", err)
+		}
+	case "toml":
+		md, err := toml.Decode(string(data), f)
+		if err != nil {
+			return fmt.Errorf("cache: parse toml profiles: %w", err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("cache: parse toml profiles: unknown keys %v", undecoded)
+		}
+	default:
+		return fmt.Errorf("cache: unsupported profile format %q", format)
+	}
+	return nil
+}
+
+// Config returns the configuration of the named profile.
+func (p *Profiles) Config(name string) (Config, error) {
+	cfg, ok := p.configs[name]
+	if !ok {
+		return Config{}, fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+	}
+	return cfg, nil
+}
+
+// Names returns the profile names in sorted order.
+func (p *Profiles) Names() []string {
+	names := make([]string, 0, len(p.configs))
+	for name := range p.configs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NewFromProfile creates a Handler[T] backed by rdb and configured by the named
+// profile. opts are applied after the profile and validated with it.
+func NewFromProfile[T any](rdb *redis.Client, p *Profiles, name string, opts ...Option) (*Handler[T], error) {
+	cfg, err := p.Config(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithConfig[T](rdb, cfg, opts...)
+}
+
+// TypedFromProfile returns a view of core configured by the named profile. The
+// profile replaces the core configuration, including its prefix.
+func TypedFromProfile[T any](core *Core, p *Profiles, name string, opts ...Option) (*Handler[T], error) {
+	cfg, err := p.Config(name)
+	if err != nil {
+		return nil, err
+	}
+	return Typed[T](core, "", append([]Option{WithConfig(cfg)}, opts...)...)
+}
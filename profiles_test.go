@@ -0,0 +1,153 @@
+package cache_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+)
+
+const yamlProfiles = `
+defaults:
+  ttl: 10m
+profiles:
+  users:
+    prefix: user
+    miss_fill_policy: coalesce
+    hit_refresh_policy: ahead
+    refresh_ahead_threshold: 0.3
+  reports:
+    prefix: report
+    ttl: 1h
+    miss_fill_policy: Stale-Or-Sync
+    stale_data_ttl: 24h
+    error_policy: zero_value
+`
+
+const jsonProfiles = `{
+  "defaults": {"ttl": "10m"},
+  "profiles": {
+    "users": {"prefix": "user", "miss_fill_policy": "coalesce", "hit_refresh_policy": "ahead", "refresh_ahead_threshold": 0.3},
+    "reports": {"prefix": "report", "ttl": "1h", "miss_fill_policy": "stale_or_sync", "stale_data_ttl": "24h", "error_policy": "zero_value"}
+  }
+}`
+
+const tomlProfiles = `
+[defaults]
+ttl = "10m"
+
+[profiles.users]
+prefix = "user"
+miss_fill_policy = "coalesce"
+hit_refresh_policy = "ahead"
+refresh_ahead_threshold = 0.3
+
+[profiles.reports]
+prefix = "report"
+ttl = "1h"
+miss_fill_policy = "stale_or_sync"
+stale_data_ttl = "24h"
+error_policy = "zero_value"
+`
+
+// TestProfiles tests loading named configuration profiles from files.
+func TestProfiles(t *testing.T) {
+	files := map[string]string{
+		"cache.yaml": yamlProfiles,
+		"cache.json": jsonProfiles,
+		"cache.toml": tomlProfiles,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			p, err := cache.LoadProfiles(path)
+			if err != nil {
+				t.Fatalf("LoadProfiles failed: %v", err)
+			}
+			if names := p.Names(); len(names) != 2 || names[0] != "reports" || names[1] != "users" {
+				t.Errorf("Expected sorted profile names, got %v", names)
+			}
+
+			users, _ := p.Config("users")
+			if users.Prefix != "user" || users.DefaultTTL != 10*time.Minute ||
+				users.MissFillPolicy != cache.MissFillCoalesce || users.HitRefreshPolicy != cache.HitRefreshAhead ||
+				users.RefreshAheadThreshold != 0.3 {
+				t.Errorf("Unexpected users profile: %+v", users)
+			}
+			reports, _ := p.Config("reports")
+			if reports.DefaultTTL != time.Hour || reports.MissFillPolicy != cache.MissFillStaleOrSync ||
+				reports.StaleDataTTL != 24*time.Hour || reports.ErrorPolicy != cache.ErrorPolicyZeroValue {
+				t.Errorf("Unexpected reports profile: %+v", reports)
+			}
+			if reports.BackgroundRefreshTimeout != cache.DefaultConfig().BackgroundRefreshTimeout {
+				t.Errorf("Expected unset fields to keep defaults, got %v", reports.BackgroundRefreshTimeout)
+			}
+		})
+	}
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		p, _ := cache.ParseProfiles([]byte(yamlProfiles), "yaml")
+		if _, err := p.Config("orders"); !errors.Is(err, cache.ErrProfileNotFound) {
+			t.Errorf("Expected ErrProfileNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		data := "profiles:\n  bad:\n    miss_fill_policy: stale_or_sync\n    stale_data_ttl: 0s\n"
+		if _, err := cache.ParseProfiles([]byte(data), "yaml"); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig, got %v", err)
+		}
+		data = "profiles:\n  bad:\n    miss_fill_policy: eventually\n"
+		if _, err := cache.ParseProfiles([]byte(data), "yaml"); err == nil {
+			t.Error("Expected unknown policy name to be rejected")
+		}
+		data = "profiles:\n  bad:\n    tll: 5m\n"
+		if _, err := cache.ParseProfiles([]byte(data), "yaml"); err == nil {
+			t.Error("Expected unknown key to be rejected")
+		}
+	})
+
+	t.Run("TypedFromProfile", func(t *testing.T) {
+		p, _ := cache.ParseProfiles([]byte(yamlProfiles), "yaml")
+		core, _ := cache.NewCoreWithBackend(cache.NewMemoryBackend())
+		h, err := cache.TypedFromProfile[string](core, p, "users")
+		if err != nil || h == nil {
+			t.Fatalf("TypedFromProfile failed: %v", err)
+		}
+	})
+}
+
+// TestPolicyNames tests the text round trip of policy values.
+func TestPolicyNames(t *testing.T) {
+	for p := cache.MissFillDefault; p <= cache.MissFillCoalesce; p++ {
+		text, _ := p.MarshalText()
+		var got cache.MissFillPolicy
+		if err := got.UnmarshalText(text); err != nil || got != p {
+			t.Errorf("Round trip of %d via %q gave %d, %v", p, text, got, err)
+		}
+	}
+	if s := cache.HitRefreshOlderThan.String(); s != "older_than" {
+		t.Errorf("Expected older_than, got %q", s)
+	}
+
+	var cfg struct {
+		Miss   cache.MissFillPolicy `json:"miss"`
+		Errors cache.ErrorPolicy    `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(`{"miss": 3, "errors": "zero_value"}`), &cfg); err != nil {
+		t.Fatalf("Expected numbers and names to decode, got %v", err)
+	}
+	if cfg.Miss != cache.MissFillStaleOrSync || cfg.Errors != cache.ErrorPolicyZeroValue {
+		t.Errorf("Expected stale_or_sync and zero_value, got %v and %v", cfg.Miss, cfg.Errors)
+	}
+	if err := json.Unmarshal([]byte(`{"miss": 99}`), &cfg); !errors.Is(err, cache.ErrInvalidConfig) {
+		t.Errorf("Expected an unknown number to be rejected, got %v", err)
+	}
+}
@@ -53,6 +53,18 @@ class MissFillPolicy(IntEnum):
     block until the lock is released or ``cooperative_timeout_secs`` elapses,
     then fall back to direct generation without caching."""
 
+    COALESCE = 6
+    """All concurrent callers for the same key share one in-flight generation
+    (singleflight). The generator runs once; every waiter receives the same value
+    or the same error."""
+
+    LEASE = 7
+    """Memcache-style lease across pods: the first caller to miss stores a lease
+    token in Redis, generates, and writes only if the token is still valid, so a
+    concurrent delete or set is never overwritten. Other callers wait briefly for
+    the value and then get a "try again" error; with Go's ``WithLeaseStale`` they
+    serve the stale copy instead if one exists."""
+
 
 class HitRefreshPolicy(IntEnum):
     """Controls proactive background refresh when the key *is* in the cache.
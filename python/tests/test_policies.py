@@ -56,9 +56,17 @@ def test_miss_fill_cooperative():
     assert MissFillPolicy.COOPERATIVE == 5
 
 
+def test_miss_fill_coalesce():
+    assert MissFillPolicy.COALESCE == 6
+
+
+def test_miss_fill_lease():
+    assert MissFillPolicy.LEASE == 7
+
+
 def test_miss_fill_policy_count():
     """Fail if new values are added to Go without being mirrored here."""
-    assert len(MissFillPolicy) == 6
+    assert len(MissFillPolicy) == 8
 
 
 # ---------------------------------------------------------------------------
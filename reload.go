@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// liveConfig holds a handler's current configuration. Every public call pins
+// the snapshot it starts with, so a swap only affects calls that begin after it.
+type liveConfig struct {
+	mu  sync.Mutex // Serialises Reconfigure's read-modify-write
+	cur atomic.Pointer[handlerConfig]
+}
+
+// pin returns a copy of h bound to the current configuration snapshot.
+// Background work started by the copy keeps that snapshot too.
+func (h *Handler[T]) pin() *Handler[T] {
+	p := *h
+	p.config = h.live.cur.Load()
+	p.applySliding(p.config.defaultTTL, callOpts{})
+	return &p
+}
+
+// Config returns the handler's current configuration.
+func (h *Handler[T]) Config() Config {
+	return h.live.cur.Load().exported()
+}
+
+// Reconfigure validates cfg and atomically makes it the handler's
+// configuration. Calls already in progress, including their background work,
+// finish with the configuration they started with. Runtime wiring set by
+// options (backend, observers, clock, schema migrations) is kept, and so is
+// the handler's prefix when cfg.Prefix is empty, so one profile can retune
+// several Typed views without moving them onto the same keys.
+// EventConfigChanged is emitted when the configuration actually changes.
+func (h *Handler[T]) Reconfigure(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	h.live.mu.Lock()
+	old := h.live.cur.Load()
+	if cfg.Prefix == "" {
+		cfg.Prefix = old.prefix
+	}
+	if old.exported() == cfg {
+		h.live.mu.Unlock()
+		return nil
+	}
+	next := *old
+	next.apply(cfg)
+	h.live.cur.Store(&next)
+	h.live.mu.Unlock()
+
+	h.pin().emit(EventConfigChanged, "", nil)
+	return nil
+}
+
+// reportConfigError emits EventConfigReloadFailed for a reload that was
+// rejected or could not be read.
+func (h *Handler[T]) reportConfigError(err error) {
+	h.pin().emit(EventConfigReloadFailed, "", err)
+}
+
+// Reconfigurable is implemented by every Handler[T], letting WatchConfig
+// reload handlers of different value types.
+type Reconfigurable interface {
+	Config() Config
+	Reconfigure(cfg Config) error
+	reportConfigError(err error)
+	after(d time.Duration) <-chan time.Time
+}
+
+var _ Reconfigurable = (*Handler[any])(nil)
+
+// ConfigSource produces the configuration WatchConfig should apply.
+type ConfigSource func(ctx context.Context) (Config, error)
+
+// FileSource reads the named profile from a profile file (see LoadProfiles)
+// each time it is called.
+func FileSource(path, profile string) ConfigSource {
+	return func(_ context.Context) (Config, error) {
+		p, err := LoadProfiles(path)
+		if err != nil {
+			return Config{}, err
+		}
+		return p.Config(profile)
+	}
+}
+
+// RedisSource reads the named profile from a JSON profile document (the
+// LoadProfiles layout) stored at key, so a fleet can be retuned by writing one
+// Redis key.
+func RedisSource(rdb redis.UniversalClient, key, profile string) ConfigSource {
+	return func(ctx context.Context) (Config, error) {
+		data, err := rdb.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return Config{}, fmt.Errorf("cache: config key %q: %w", key, ErrNotFound)
+		}
+		if err != nil {
+			return Config{}, &BackendError{Op: "get", Key: key, Err: err}
+		}
+		p, err := ParseProfiles(data, "json")
+		if err != nil {
+			return Config{}, fmt.Errorf("cache: config key %q: %w", key, err)
+		}
+		return p.Config(profile)
+	}
+}
+
+// WatchConfig polls src every interval and applies its result to every target
+// whose configuration differs. A source or validation error leaves the targets
+// unchanged and emits EventConfigReloadFailed on each of them. The interval is
+// measured on the first target's clock (see TimerClock) from the end of each
+// reload. A profile that sets a prefix moves every target onto it, so leave
+// the prefix unset in profiles shared by several Typed views. WatchConfig
+// blocks until ctx is done and returns ctx.Err().
+func WatchConfig(ctx context.Context, src ConfigSource, interval time.Duration, targets ...Reconfigurable) error {
+	after := time.After
+	if len(targets) > 0 {
+		after = targets[0].after
+	}
+	for {
+		reloadConfig(ctx, src, targets)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-after(interval):
+		}
+	}
+}
+
+func reloadConfig(ctx context.Context, src ConfigSource, targets []Reconfigurable) {
+	cfg, err := src(ctx)
+	for _, t := range targets {
+		if err != nil {
+			t.reportConfigError(err)
+			continue
+		}
+		if rerr := t.Reconfigure(cfg); rerr != nil {
+			t.reportConfigError(rerr)
+		}
+	}
+}
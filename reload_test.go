@@ -0,0 +1,176 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestReconfigure tests swapping a handler's configuration at runtime.
+func TestReconfigure(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Applies to subsequent calls", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		events := make(chan cache.Event, 10)
+		h, _ := cache.NewWithBackend[string](b,
+			cache.WithDefaultTTL(time.Minute),
+			cache.WithObserver(func(e cache.Event) {
+				if e.Kind == cache.EventConfigChanged || e.Kind == cache.EventConfigReloadFailed {
+					events <- e
+				}
+			}),
+		)
+
+		cfg := h.Config()
+		cfg.DefaultTTL = time.Hour
+		if err := h.Reconfigure(cfg); err != nil {
+			t.Fatalf("Reconfigure failed: %v", err)
+		}
+		if e := <-events; e.Kind != cache.EventConfigChanged {
+			t.Errorf("Expected EventConfigChanged, got %v", e.Kind)
+		}
+		if err := h.Reconfigure(cfg); err != nil || len(events) != 0 {
+			t.Errorf("Expected an unchanged config to be a silent no-op, got %v", err)
+		}
+
+		_ = h.Set(ctx, "key", "value")
+		if ttl, _ := b.TTL(ctx, "key"); ttl <= time.Minute {
+			t.Errorf("Expected the new one-hour TTL, got %v", ttl)
+		}
+
+		cfg.RefreshAheadThreshold = 3
+		if err := h.Reconfigure(cfg); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig, got %v", err)
+		}
+		if h.Config().RefreshAheadThreshold == 3 {
+			t.Error("Expected an invalid config to leave the handler unchanged")
+		}
+	})
+
+	t.Run("In-flight calls keep their config", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		h, _ := cache.NewWithBackend[string](b, cache.WithDefaultTTL(time.Minute))
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			_, err := h.GetOrRefresh(ctx, "key", func(_ context.Context) (string, error) {
+				close(started)
+				<-release
+				return "value", nil
+			})
+			done <- err
+		}()
+
+		<-started
+		cfg := h.Config()
+		cfg.DefaultTTL = time.Hour
+		_ = h.Reconfigure(cfg)
+		close(release)
+		if err := <-done; err != nil {
+			t.Fatalf("GetOrRefresh failed: %v", err)
+		}
+		if ttl, _ := b.TTL(ctx, "key"); ttl > time.Minute {
+			t.Errorf("Expected the in-flight fill to keep the one-minute TTL, got %v", ttl)
+		}
+	})
+
+	t.Run("Views keep their prefix", func(t *testing.T) {
+		core, _ := cache.NewCoreWithBackend(cache.NewMemoryBackend(), cache.WithPrefix("app"))
+		users, _ := cache.Typed[string](core, "user")
+		counts, _ := cache.Typed[int](core, "count")
+
+		cfg := cache.DefaultConfig()
+		cfg.DefaultTTL = time.Hour
+		for _, h := range []cache.Reconfigurable{users, counts} {
+			if err := h.Reconfigure(cfg); err != nil {
+				t.Fatalf("Reconfigure failed: %v", err)
+			}
+		}
+		if p := users.Config().Prefix; p != "app:user" {
+			t.Errorf("Expected users to keep app:user, got %q", p)
+		}
+		if p := counts.Config().Prefix; p != "app:count" {
+			t.Errorf("Expected counts to keep app:count, got %q", p)
+		}
+
+		cfg.Prefix = "moved"
+		_ = users.Reconfigure(cfg)
+		if p := users.Config().Prefix; p != "moved" {
+			t.Errorf("Expected a profile prefix to apply, got %q", p)
+		}
+	})
+}
+
+// TestWatchConfig tests reloading handler configuration from a profile file.
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	write := func(ttl string) {
+		data := "profiles:\n  users:\n    ttl: " + ttl + "\n"
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("10m")
+
+	events := make(chan cache.Event, 10)
+	observe := cache.WithObserver(func(e cache.Event) {
+		if e.Kind == cache.EventConfigChanged || e.Kind == cache.EventConfigReloadFailed {
+			select {
+			case events <- e:
+			default: // The watcher repeats failures every tick; drop the overflow
+			}
+		}
+	})
+	users, _ := cachetest.NewHandler[string](observe)
+	counts, _ := cache.NewWithBackend[int](cache.NewMemoryBackend(), observe, cache.WithClock(users.Clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- cache.WatchConfig(ctx, cache.FileSource(path, "users"), time.Minute, users.Handler, counts)
+	}()
+
+	next := func() cache.Event {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for a config event")
+			return cache.Event{}
+		}
+	}
+
+	for range 2 {
+		if e := next(); e.Kind != cache.EventConfigChanged {
+			t.Fatalf("Expected EventConfigChanged, got %v (%v)", e.Kind, e.Err)
+		}
+	}
+	if users.Config().DefaultTTL != 10*time.Minute || counts.Config().DefaultTTL != 10*time.Minute {
+		t.Errorf("Expected both handlers to load the profile TTL")
+	}
+
+	write("-1m")
+	users.Clock.BlockUntil(1) // Waiting for the next reload
+	users.Clock.Advance(time.Minute)
+	if e := next(); e.Kind != cache.EventConfigReloadFailed || !errors.Is(e.Err, cache.ErrInvalidConfig) {
+		t.Errorf("Expected EventConfigReloadFailed with ErrInvalidConfig, got %v (%v)", e.Kind, e.Err)
+	}
+	if users.Config().DefaultTTL != 10*time.Minute {
+		t.Error("Expected a failed reload to keep the previous config")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WithReadClient routes read commands (GET and the TTL lookups behind the
+// hit-refresh policies) to a separate client, typically one connected to a
+// replica or created with redis.Options.ReadOnly on a cluster. Writes, deletes
+// and the existence checks made before background writes and lease fills stay
+// on the primary client passed to New.
+func WithReadClient(rdb *redis.Client) Option {
+	return WithReadBackend(NewRedisBackend(rdb))
+}
+
+// WithReadBackend is the Backend form of WithReadClient.
+func WithReadBackend(b Backend) Option {
+	return func(c *handlerConfig) { c.readBackend = b }
+}
+
+// WithReadYourWrites makes reads of a key go to the primary for window after
+// this process wrote it, so a caller never observes replication lag on its own
+// writes. It has no effect without WithReadClient. The guarantee is per process.
+func WithReadYourWrites(window time.Duration) Option {
+	return func(c *handlerConfig) {
+		if window > 0 {
+			c.readYourWritesWindow = window
+		}
+	}
+}
+
+// reader returns the backend that should serve reads of fullKey: the read
+// backend when one is configured, unless this process wrote the key within the
+// read-your-writes window.
+func (h *Handler[T]) reader(fullKey string) Backend {
+	if h.config.readBackend == nil {
+		return h.config.backend
+	}
+	if h.config.readYourWritesWindow > 0 {
+		h.core.lastRefreshMu.Lock()
+		until, ok := h.core.readPrimaryUntil[fullKey]
+		h.core.lastRefreshMu.Unlock()
+		if ok && h.now().Before(until) {
+			return h.config.backend
+		}
+	}
+	return h.config.readBackend
+}
+
+// recordWrite notes that this process just wrote fullKey, for read-your-writes
+// routing. It is a no-op unless both a read client and a window are configured.
+// Keys whose windows have ended are swept at most once per window, so the
+// bookkeeping stays bounded by the keys written within the last window or two.
+func (h *Handler[T]) recordWrite(fullKey string) {
+	if h.config.readBackend == nil || h.config.readYourWritesWindow <= 0 {
+		return
+	}
+	now := h.now()
+	h.core.lastRefreshMu.Lock()
+	defer h.core.lastRefreshMu.Unlock()
+	if !now.Before(h.core.nextWriteSweep) {
+		for k, until := range h.core.readPrimaryUntil {
+			if !now.Before(until) {
+				delete(h.core.readPrimaryUntil, k)
+			}
+		}
+		h.core.nextWriteSweep = now.Add(h.config.readYourWritesWindow)
+	}
+	until := now.Add(h.config.readYourWritesWindow)
+	if until.After(h.core.readPrimaryUntil[fullKey]) { // Keep the longest window when views differ
+		h.core.readPrimaryUntil[fullKey] = until
+	}
+}
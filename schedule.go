@@ -0,0 +1,279 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ---------------------------
+// Scheduled refresh
+// ---------------------------
+
+// Scheduler refreshes registered keys proactively, without waiting for a read
+// to trigger a hit refresh, so rarely read but expensive keys do not expire
+// into a slow miss. Refreshes run as background tasks that take the same
+// per-key stampede lock as hit refreshes, honour the refresh cooldown and
+// emit EventBackgroundRefresh.
+//
+// A key is refreshed when it is missing, when its remaining TTL falls under
+// the registration's refresh-ahead threshold, or when its interval has passed
+// since the scheduler last refreshed it successfully. Keys not read through
+// Get or GetOrRefresh for the idle timeout are deregistered and reported with
+// EventScheduleIdle.
+type Scheduler[T any] struct {
+	h           *Handler[T]
+	tick        time.Duration
+	idleTimeout time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*scheduledKey[T]
+}
+
+type scheduledKey[T any] struct {
+	gen        EntryGenerator[T]
+	every      time.Duration
+	opts       []CallOption
+	registered time.Time
+	refreshed  time.Time // Last time a scheduled refresh of the key succeeded
+}
+
+// NewScheduler creates a Scheduler for h that checks its keys every tick. An
+// idleTimeout <= 0 keeps keys registered until Deregister.
+func NewScheduler[T any](h *Handler[T], tick, idleTimeout time.Duration) *Scheduler[T] {
+	return &Scheduler[T]{
+		h:           h,
+		tick:        tick,
+		idleTimeout: idleTimeout,
+		jobs:        make(map[string]*scheduledKey[T]),
+	}
+}
+
+// Register schedules key to be refreshed with gen every interval, counted
+// from the last successful refresh. Independently of the interval, the key is
+// refreshed when it is missing or its remaining TTL falls under the
+// refresh-ahead threshold: the one set by WithCallRefreshAheadThreshold in
+// opts, or the handler default. An interval <= 0 relies on those checks
+// alone. opts also select the TTL and sliding expiration of written entries.
+// Registering a key again replaces its schedule.
+func (s *Scheduler[T]) Register(key string, gen Generator[T], every time.Duration, opts ...CallOption) {
+	s.RegisterEntry(key, entryGenerator(gen), every, opts...)
+}
+
+// RegisterEntry is Register for a generator that reports each value's TTL or
+// opts out of caching it, as with GetOrRefreshEntry.
+func (s *Scheduler[T]) RegisterEntry(key string, gen EntryGenerator[T], every time.Duration, opts ...CallOption) {
+	now := s.h.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[key]; !ok {
+		s.h.core.reads.watch(s.h.fullKey(key))
+	}
+	s.jobs[key] = &scheduledKey[T]{
+		gen:        gen,
+		every:      every,
+		opts:       opts,
+		registered: now,
+		refreshed:  now,
+	}
+}
+
+// Deregister stops refreshing keys. Entries already cached are left to expire.
+func (s *Scheduler[T]) Deregister(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		s.remove(key)
+	}
+}
+
+func (s *Scheduler[T]) remove(key string) {
+	if _, ok := s.jobs[key]; ok {
+		delete(s.jobs, key)
+		s.h.core.reads.unwatch(s.h.fullKey(key))
+	}
+}
+
+// Keys returns the registered keys in sorted order.
+func (s *Scheduler[T]) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.jobs))
+	for k := range s.jobs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// RunOnce checks every registered key once, deregistering idle keys and
+// starting a background refresh for each key that is due. It returns the
+// first backend error met while checking freshness; the other keys are still
+// checked.
+func (s *Scheduler[T]) RunOnce(ctx context.Context) error {
+	var firstErr error
+	for _, key := range s.Keys() {
+		if err := s.check(ctx, key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// check deregisters key if idle, or refreshes it if due.
+func (s *Scheduler[T]) check(ctx context.Context, key string) error {
+	h := s.h.pin()
+	fullKey := h.fullKey(key)
+	now := h.now()
+
+	s.mu.Lock()
+	job, ok := s.jobs[key]
+	if !ok {
+		s.mu.Unlock()
+		return nil
+	}
+	if s.idleTimeout > 0 {
+		lastUse := job.registered
+		if read, ok := h.core.reads.last(fullKey); ok && read.After(lastUse) {
+			lastUse = read
+		}
+		if now.Sub(lastUse) >= s.idleTimeout {
+			s.remove(key)
+			s.mu.Unlock()
+			h.emit(EventScheduleIdle, fullKey, nil)
+			return nil
+		}
+	}
+	j := *job
+	s.mu.Unlock()
+
+	var co callOpts
+	for _, o := range j.opts {
+		o(&co)
+	}
+	ttl := co.ttl
+	if ttl <= 0 {
+		ttl = h.config.defaultTTL
+	}
+	h.applySliding(ttl, co)
+	threshold := co.refreshAheadThreshold
+	if threshold <= 0 {
+		threshold = h.config.defaultRefreshAheadThreshold
+	}
+
+	due := j.every > 0 && now.Sub(j.refreshed) >= j.every
+	if !due {
+		fresh, err := h.isFresh(ctx, fullKey, ttl, threshold)
+		if err != nil {
+			return err
+		}
+		due = !fresh
+	}
+	if !due {
+		return nil
+	}
+
+	gen := h.observeGenerator(fullKey, j.gen)
+	h.background(func() {
+		h.spawnBackgroundRefresh(key, ttl, func(ctx context.Context) (Entry[T], error) {
+			e, err := gen(ctx)
+			if err == nil {
+				s.markRefreshed(key, h.now())
+			}
+			return e, err
+		})
+	})
+	return nil
+}
+
+// markRefreshed restarts the interval of key after a successful refresh.
+// Skipped and failed refreshes leave the key due on the next pass.
+func (s *Scheduler[T]) markRefreshed(key string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[key]; ok {
+		job.refreshed = at
+	}
+}
+
+// Run calls RunOnce every tick until ctx is done and returns ctx.Err(). The
+// tick is measured on the handler's clock (see TimerClock) from the end of
+// each pass. Errors from individual passes are dropped; the next pass retries.
+func (s *Scheduler[T]) Run(ctx context.Context) error {
+	for {
+		_ = s.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.h.after(s.tick):
+		}
+	}
+}
+
+// readTracker records when scheduled keys were last read, so a Scheduler can
+// tell which keys went idle. Keys nobody watches are not tracked, keeping the
+// read path free of bookkeeping when no Scheduler is in use.
+type readTracker struct {
+	watched atomic.Int32
+	mu      sync.Mutex
+	keys    map[string]*readState
+}
+
+type readState struct {
+	watchers int
+	last     time.Time
+}
+
+func newReadTracker() *readTracker {
+	return &readTracker{keys: make(map[string]*readState)}
+}
+
+func (r *readTracker) watch(fullKey string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.keys[fullKey]
+	if !ok {
+		st = &readState{}
+		r.keys[fullKey] = st
+		r.watched.Add(1)
+	}
+	st.watchers++
+}
+
+func (r *readTracker) unwatch(fullKey string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.keys[fullKey]
+	if !ok {
+		return
+	}
+	if st.watchers--; st.watchers == 0 {
+		delete(r.keys, fullKey)
+		r.watched.Add(-1)
+	}
+}
+
+// touch records a read of fullKey if it is watched.
+func (r *readTracker) touch(fullKey string, now time.Time) {
+	if r.watched.Load() == 0 {
+		return
+	}
+	r.mu.Lock()
+	if st, ok := r.keys[fullKey]; ok {
+		st.last = now
+	}
+	r.mu.Unlock()
+}
+
+// last returns the last recorded read of fullKey.
+func (r *readTracker) last(fullKey string) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.keys[fullKey]
+	if !ok || st.last.IsZero() {
+		return time.Time{}, false
+	}
+	return st.last, true
+}
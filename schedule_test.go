@@ -0,0 +1,197 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestScheduler tests traffic-independent refreshes of registered keys.
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	ttl := cache.WithDefaultTTL(10 * time.Minute)
+	counter := func() (cache.Generator[int], *int) {
+		calls := 0
+		return func(_ context.Context) (int, error) { calls++; return calls, nil }, &calls
+	}
+
+	t.Run("Refreshes on an interval without reads", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[int](ttl)
+		gen, calls := counter()
+		s := cache.NewScheduler(h.Handler, time.Second, 0)
+		s.Register("report", gen, 5*time.Minute)
+
+		_ = s.RunOnce(ctx)
+		if *calls != 1 {
+			t.Fatalf("Expected a missing key to be filled at once, got %d calls", *calls)
+		}
+		h.Clock.Advance(4 * time.Minute)
+		_ = s.RunOnce(ctx)
+		if *calls != 1 {
+			t.Errorf("Expected no refresh before the interval, got %d calls", *calls)
+		}
+		h.Clock.Advance(time.Minute)
+		_ = s.RunOnce(ctx)
+		if r, _ := h.Get(ctx, "report"); *calls != 2 || r.Value != 2 {
+			t.Errorf("Expected a refresh after the interval, got %d calls and %d", *calls, r.Value)
+		}
+	})
+
+	t.Run("Refreshes ahead of expiry", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[int](ttl)
+		gen, calls := counter()
+		_ = h.Set(ctx, "report", 0)
+		s := cache.NewScheduler(h.Handler, time.Second, 0)
+		s.Register("report", gen, 0, cache.WithCallRefreshAheadThreshold(0.2))
+
+		h.Clock.Advance(7 * time.Minute)
+		_ = s.RunOnce(ctx)
+		if *calls != 0 {
+			t.Fatalf("Expected a fresh key to be left alone, got %d calls", *calls)
+		}
+		h.Clock.Advance(2 * time.Minute) // 10% of the TTL left
+		_ = s.RunOnce(ctx)
+		if *calls != 1 {
+			t.Errorf("Expected a refresh under the threshold, got %d calls", *calls)
+		}
+	})
+
+	t.Run("Falls back to the handler threshold", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[int](ttl, cache.WithRefreshAheadThreshold(0.2))
+		gen, calls := counter()
+		_ = h.Set(ctx, "report", 0)
+		s := cache.NewScheduler(h.Handler, time.Second, 0)
+		s.Register("report", gen, 0)
+
+		h.Clock.Advance(9 * time.Minute)
+		_ = s.RunOnce(ctx)
+		if *calls != 1 {
+			t.Errorf("Expected a refresh under the handler threshold, got %d calls", *calls)
+		}
+	})
+
+	t.Run("Failed refreshes stay due", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[int](ttl)
+		calls := 0
+		s := cache.NewScheduler(h.Handler, time.Second, 0)
+		s.Register("report", func(_ context.Context) (int, error) {
+			calls++
+			if calls == 2 {
+				return 0, errors.New("boom")
+			}
+			return calls, nil
+		}, 5*time.Minute)
+		_ = s.RunOnce(ctx)
+
+		h.Clock.Advance(5 * time.Minute)
+		_ = s.RunOnce(ctx) // Fails
+		_ = s.RunOnce(ctx)
+		if r, _ := h.Get(ctx, "report"); calls != 3 || r.Value != 3 {
+			t.Errorf("Expected the next pass to retry the failed refresh, got %d calls and %d", calls, r.Value)
+		}
+		_ = s.RunOnce(ctx)
+		if calls != 3 {
+			t.Errorf("Expected the successful retry to restart the interval, got %d calls", calls)
+		}
+	})
+
+	t.Run("Skips keys being refreshed", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[int](ttl)
+		gen, calls := counter()
+		s := cache.NewScheduler(h.Handler, time.Second, 0)
+		s.Register("report", gen, time.Minute)
+		_ = s.RunOnce(ctx)
+
+		// A concurrent miss fill of "report" holds the stampede lock.
+		_ = h.Delete(ctx, "report")
+		release := make(chan struct{})
+		blocked := make(chan struct{})
+		go func() {
+			_, _ = h.GetOrRefresh(ctx, "report", func(_ context.Context) (int, error) {
+				close(blocked)
+				<-release
+				return 0, nil
+			})
+		}()
+		<-blocked
+		h.Clock.Advance(time.Minute)
+		_ = s.RunOnce(ctx)
+		close(release)
+		if *calls != 1 {
+			t.Errorf("Expected the scheduled refresh to yield to the lock holder, got %d calls", *calls)
+		}
+	})
+
+	t.Run("Deregister and idle timeout", func(t *testing.T) {
+		var idle []string
+		h, _ := cachetest.NewHandler[int](ttl, cache.WithObserver(func(e cache.Event) {
+			if e.Kind == cache.EventScheduleIdle {
+				idle = append(idle, e.Key)
+			}
+		}))
+		gen, _ := counter()
+		s := cache.NewScheduler(h.Handler, time.Second, 10*time.Minute)
+		s.Register("read", gen, time.Minute)
+		s.Register("unread", gen, time.Minute)
+		s.Register("dropped", gen, time.Minute)
+		s.Deregister("dropped")
+		_ = s.RunOnce(ctx)
+
+		h.Clock.Advance(6 * time.Minute)
+		_, _ = h.Get(ctx, "read")
+		h.Clock.Advance(5 * time.Minute)
+		_ = s.RunOnce(ctx)
+
+		if keys := s.Keys(); len(keys) != 1 || keys[0] != "read" {
+			t.Errorf("Expected only the read key to stay registered, got %v", keys)
+		}
+		if len(idle) != 1 || idle[0] != "unread" {
+			t.Errorf("Expected EventScheduleIdle for unread, got %v", idle)
+		}
+	})
+
+	t.Run("Entry generators set the TTL", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[int](ttl)
+		s := cache.NewScheduler(h.Handler, time.Second, 0)
+		s.RegisterEntry("rates", func(_ context.Context) (cache.Entry[int], error) {
+			return cache.Entry[int]{Value: 7, TTL: time.Minute}, nil
+		}, 0)
+
+		_ = s.RunOnce(ctx)
+		if ttl, err := h.Backend.TTL(ctx, "rates"); err != nil || ttl != time.Minute {
+			t.Errorf("Expected the entry's 1m TTL, got %v, %v", ttl, err)
+		}
+	})
+
+	t.Run("Run ticks on the handler clock", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[int](ttl)
+		gen, calls := counter()
+		s := cache.NewScheduler(h.Handler, time.Minute, 0)
+		s.Register("report", gen, 5*time.Minute)
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- s.Run(runCtx) }()
+		h.Clock.BlockUntil(1)
+		if *calls != 1 {
+			t.Fatalf("Expected Run to fill the key at once, got %d calls", *calls)
+		}
+		for range 5 {
+			h.Clock.Advance(time.Minute)
+			h.Clock.BlockUntil(1)
+		}
+		if *calls != 2 {
+			t.Errorf("Expected one refresh after five 1m ticks, got %d calls", *calls)
+		}
+
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ---------------------------
+// Sliding expiration
+// ---------------------------
+
+// TouchBackend is implemented by backends that can read a key and extend its
+// TTL in the same round trip, which sliding expiration needs. Reads on other
+// backends return the value without extending it.
+type TouchBackend interface {
+	// GetTouch returns the value under key and resets its TTL to ttl. When
+	// limitKey is non-empty the new TTL never exceeds limitKey's remaining TTL,
+	// and a missing limitKey leaves the TTL unchanged.
+	GetTouch(ctx context.Context, key string, ttl time.Duration, limitKey string) ([]byte, error)
+}
+
+// WithSlidingExpiration makes every hit reset the entry's TTL to the call or
+// handler TTL, so entries live as long as they are read. An entry is never
+// kept beyond maxLifetime after it was written. Requires a TouchBackend (both
+// bundled backends are).
+//
+// Refresh-ahead and older-than hit refresh policies measure an entry's age
+// from its write time against maxLifetime while sliding expiration is on.
+func WithSlidingExpiration(maxLifetime time.Duration) Option {
+	return func(c *handlerConfig) {
+		c.slidingExpiration = true
+		c.maxLifetime = maxLifetime
+	}
+}
+
+// WithCallSlidingExpiration enables sliding expiration for a single call (see
+// WithSlidingExpiration). A maxLifetime <= 0 disables it for the call.
+func WithCallSlidingExpiration(maxLifetime time.Duration) CallOption {
+	return func(c *callOpts) { c.slidingMaxLifetime = &maxLifetime }
+}
+
+// slidingState is the sliding expiration in effect for a pinned call.
+type slidingState struct {
+	ttl         time.Duration // TTL granted on each hit
+	maxLifetime time.Duration // > 0 when sliding is enabled
+}
+
+// applySliding resolves sliding expiration for a call with the given TTL.
+func (h *Handler[T]) applySliding(ttl time.Duration, co callOpts) {
+	h.sliding = slidingState{ttl: ttl}
+	if h.config.slidingExpiration {
+		h.sliding.maxLifetime = h.config.maxLifetime
+	}
+	if co.slidingMaxLifetime != nil {
+		h.sliding.maxLifetime = max(*co.slidingMaxLifetime, 0)
+	}
+}
+
+// lifetimeKey returns the key whose TTL bounds fullKey's absolute lifetime.
+// The hash tag keeps both keys in the same Redis Cluster slot.
+func lifetimeKey(fullKey string) string {
+	return "{" + fullKey + "}:lifetime"
+}
+
+// getMainRaw fetches the bytes of a main entry, extending its TTL when sliding
+// expiration is enabled. Touching is a write, so it always goes to the primary.
+func (h *Handler[T]) getMainRaw(ctx context.Context, fullKey string) ([]byte, error) {
+	if h.sliding.maxLifetime <= 0 {
+		return h.getRaw(ctx, fullKey)
+	}
+	tb, ok := h.config.backend.(TouchBackend)
+	if !ok {
+		return h.getRaw(ctx, fullKey)
+	}
+	raw, err := tb.GetTouch(ctx, fullKey, h.jitterTTL(fullKey, h.sliding.ttl), lifetimeKey(fullKey))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, &BackendError{Op: "getex", Key: fullKey, Err: err}
+	}
+	return raw, nil
+}
+
+// capLifetime returns the TTL a new entry should be written with: ttl capped
+// at maxLifetime while sliding expiration is on.
+func (h *Handler[T]) capLifetime(ttl time.Duration) time.Duration {
+	if h.sliding.maxLifetime <= 0 {
+		return ttl
+	}
+	return min(ttl, h.sliding.maxLifetime)
+}
+
+// startLifetime starts the lifetime marker of a freshly written entry.
+func (h *Handler[T]) startLifetime(ctx context.Context, fullKey string) error {
+	if h.sliding.maxLifetime <= 0 {
+		return nil
+	}
+	if err := h.config.backend.Set(ctx, lifetimeKey(fullKey), []byte{'1'}, h.sliding.maxLifetime); err != nil {
+		return &BackendError{Op: "set", Key: lifetimeKey(fullKey), Err: err}
+	}
+	return nil
+}
+
+// ageReference returns the key and original TTL that TTL-based hit refresh
+// policies measure an entry's age against.
+func (h *Handler[T]) ageReference(fullKey string, ttl time.Duration) (string, time.Duration) {
+	if h.sliding.maxLifetime > 0 {
+		return lifetimeKey(fullKey), h.sliding.maxLifetime
+	}
+	return fullKey, ttl
+}
+
+// touchScript reads KEYS[1] and extends it to ARGV[1] milliseconds, capped at
+// the remaining TTL of KEYS[2]; a missing KEYS[2] leaves the TTL unchanged.
+var touchScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if not v then return false end
+local ttl = tonumber(ARGV[1])
+local limit = redis.call('PTTL', KEYS[2])
+if limit == -2 then return v end
+if limit >= 0 and limit < ttl then ttl = limit end
+if ttl > 0 then redis.call('PEXPIRE', KEYS[1], ttl) end
+return v
+`)
+
+func (b *RedisBackend) GetTouch(ctx context.Context, key string, ttl time.Duration, limitKey string) ([]byte, error) {
+	var raw []byte
+	var err error
+	if limitKey == "" {
+		raw, err = b.rdb.GetEx(ctx, key, ttl).Bytes()
+	} else {
+		var s string
+		s, err = touchScript.Run(ctx, b.rdb, []string{key, limitKey}, ttl.Milliseconds()).Text()
+		raw = []byte(s)
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return raw, err
+}
+
+func (m *MemoryBackend) GetTouch(_ context.Context, key string, ttl time.Duration, limitKey string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.live(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if ttl <= 0 {
+		return bytes.Clone(e.value), nil
+	}
+	expiresAt := m.clock.Now().Add(ttl)
+	if limitKey != "" {
+		limit, ok := m.live(limitKey)
+		if !ok {
+			return bytes.Clone(e.value), nil
+		}
+		if !limit.expiresAt.IsZero() && limit.expiresAt.Before(expiresAt) {
+			expiresAt = limit.expiresAt
+		}
+	}
+	e.expiresAt = expiresAt
+	m.entries[key] = e
+	return bytes.Clone(e.value), nil
+}
+
+var (
+	_ TouchBackend = (*RedisBackend)(nil)
+	_ TouchBackend = (*MemoryBackend)(nil)
+)
@@ -0,0 +1,128 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestSlidingExpiration tests touch-on-read TTLs bounded by a max lifetime.
+func TestSlidingExpiration(t *testing.T) {
+	ctx := context.Background()
+
+	minute := cache.WithDefaultTTL(time.Minute)
+
+	t.Run("Reads extend the entry", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](minute, cache.WithSlidingExpiration(5*time.Minute))
+		_ = h.Set(ctx, "session", "data")
+
+		for range 3 {
+			h.Clock.Advance(50 * time.Second)
+			if _, err := h.Get(ctx, "session"); err != nil {
+				t.Fatalf("Expected a read within the window to hit, got %v", err)
+			}
+		}
+		h.Clock.Advance(61 * time.Second)
+		if _, err := h.Get(ctx, "session"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected an idle entry to expire, got %v", err)
+		}
+	})
+
+	t.Run("Max lifetime caps extensions", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](minute, cache.WithSlidingExpiration(90*time.Second))
+		_ = h.Set(ctx, "session", "data")
+
+		h.Clock.Advance(50 * time.Second)
+		_, _ = h.Get(ctx, "session")
+		h.Clock.Advance(41 * time.Second)
+		if _, err := h.Get(ctx, "session"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected the entry to expire at its max lifetime, got %v", err)
+		}
+	})
+
+	t.Run("Per-call", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](minute)
+		gen := func(_ context.Context) (string, error) { return "data", nil }
+		sliding := cache.WithCallSlidingExpiration(time.Hour)
+
+		_, _ = h.GetOrRefresh(ctx, "session", gen, sliding, cache.WithoutBackgroundRefresh())
+		h.Clock.Advance(50 * time.Second)
+		_, _ = h.GetOrRefresh(ctx, "session", gen, sliding, cache.WithoutBackgroundRefresh())
+		h.Clock.Advance(50 * time.Second)
+		if _, err := h.Get(ctx, "session"); err != nil {
+			t.Errorf("Expected the per-call touch to keep the entry alive, got %v", err)
+		}
+	})
+
+	t.Run("Refresh-ahead measures lifetime", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](minute,
+			cache.WithSlidingExpiration(10*time.Minute),
+			cache.WithDefaultHitRefreshPolicy(cache.HitRefreshAhead),
+			cache.WithRefreshAheadThreshold(0.2),
+		)
+		calls := 0
+		gen := func(_ context.Context) (string, error) { calls++; return "data", nil }
+
+		_, _ = h.GetOrRefresh(ctx, "session", gen)
+		for range 8 {
+			h.Clock.Advance(55 * time.Second)
+			_, _ = h.GetOrRefresh(ctx, "session", gen)
+		}
+		if calls != 1 {
+			t.Fatalf("Expected no refresh early in the lifetime, got %d generator calls", calls)
+		}
+		h.Clock.Advance(55 * time.Second) // 8m15s of 10m: under 20% left
+		_, _ = h.GetOrRefresh(ctx, "session", gen)
+		if calls != 2 {
+			t.Errorf("Expected a refresh ahead of the max lifetime, got %d generator calls", calls)
+		}
+	})
+
+	t.Run("Local hot key hits extend the entry", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](minute,
+			cache.WithSlidingExpiration(time.Hour),
+			cache.WithHotKeyDetection(time.Minute, 10),
+			cache.WithHotKeySampleRate(1),
+			cache.WithHotKeyPromotion(1, 10*time.Minute),
+		)
+		_ = h.Set(ctx, "session", "data")
+		_, _ = h.Get(ctx, "session") // Promotes the key
+
+		for range 3 {
+			h.Clock.Advance(50 * time.Second)
+			if r, err := h.Get(ctx, "session"); err != nil || !r.FromCache {
+				t.Fatalf("Expected a local hit, got %+v, %v", r, err)
+			}
+		}
+		if ttl, err := h.Backend.TTL(ctx, "session"); err != nil || ttl != time.Minute {
+			t.Errorf("Expected the last local hit to reset the TTL to 1m, got %v, %v", ttl, err)
+		}
+	})
+
+	t.Run("Redis uses GETEX", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		b := cache.NewRedisBackend(db)
+		mock.ExpectGetEx("k", time.Minute).SetVal("v")
+		raw, err := b.GetTouch(ctx, "k", time.Minute, "")
+		if err != nil || string(raw) != "v" {
+			t.Errorf("Expected v, got %q, %v", raw, err)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		cfg := cache.DefaultConfig()
+		cfg.SlidingExpiration = true
+		if err := cfg.Validate(); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig without MaxLifetime, got %v", err)
+		}
+	})
+}
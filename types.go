@@ -2,7 +2,6 @@ package cache
 
 import (
 	"context"
-	"errors"
 	"time"
 )
 
@@ -16,15 +15,43 @@ type Result[T any] struct {
 // Generator is the function that produces fresh data.
 type Generator[T any] func(ctx context.Context) (T, error)
 
+// Entry is a generated value together with its freshness, for upstreams that
+// know how long their data stays valid (Cache-Control max-age, expires_at).
+type Entry[T any] struct {
+	Value   T
+	TTL     time.Duration // Cache lifetime; <= 0 uses the call or handler TTL
+	NoCache bool          // Return Value to the caller without storing it
+}
+
+// EntryGenerator produces fresh data together with its TTL. See
+// Handler.GetOrRefreshEntry.
+type EntryGenerator[T any] func(ctx context.Context) (Entry[T], error)
+
+// entryGenerator adapts a Generator to an EntryGenerator that defers to the
+// call or handler TTL.
+func entryGenerator[T any](gen Generator[T]) EntryGenerator[T] {
+	return func(ctx context.Context) (Entry[T], error) {
+		v, err := gen(ctx)
+		return Entry[T]{Value: v}, err
+	}
+}
+
+// Cache is the behaviour shared by Handler[T] and the decorators in this
+// package. Depend on it instead of *Handler[T] to wrap a cache with logging,
+// metrics or fakes.
+type Cache[T any] interface {
+	Get(ctx context.Context, key string) (Result[T], error)
+	Set(ctx context.Context, key string, value T, opts ...CallOption) error
+	Delete(ctx context.Context, key string) error
+	GetOrRefresh(ctx context.Context, key string, gen Generator[T], opts ...CallOption) (Result[T], error)
+}
+
 // Option configures the handlerConfig.
 type Option func(*handlerConfig)
 
 // CallOption configures a single call.
 type CallOption func(*callOpts)
 
-// ErrCacheMiss is returned when MissFillFailFast is active and the key is not in the cache.
-var ErrCacheMiss = errors.New("cache miss")
-
 type callOpts struct {
 	ttl                      time.Duration
 	disableHitRefresh        bool
@@ -35,4 +62,7 @@ type callOpts struct {
 	probabilisticRefreshBeta float64       // Beta parameter for probabilistic refresh (default: 1.0)
 	refreshOlderThanAge      time.Duration // Age threshold for HitRefreshOlderThan
 	staleCheckTimeout        time.Duration // Timeout for checking stale data
+	slidingMaxLifetime       *time.Duration
+	warmConcurrency          int  // Maximum keys Warm generates at once
+	updateRetries            *int // Conflict retries for Update
 }
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ---------------------------
+// Optimistic updates
+// ---------------------------
+
+// defaultUpdateRetries is how often Update retries a lost race when
+// WithUpdateRetries is not given.
+const defaultUpdateRetries = 10
+
+// UpdateFunc computes the new value of a key from its current value. exists is
+// false when the key is absent, in which case old is the zero value. It may be
+// called several times for one Update and must not have side effects.
+type UpdateFunc[T any] func(old T, exists bool) (T, error)
+
+// WithUpdateRetries sets how many times Update retries after another writer
+// changed the key between its read and its write. n < 0 is treated as 0.
+func WithUpdateRetries(n int) CallOption {
+	return func(c *callOpts) { c.updateRetries = &n }
+}
+
+// Update performs a read-modify-write of key that is safe across processes:
+// it reads the current value, applies fn and writes the result only if the
+// stored bytes are unchanged (AtomicBackend.CompareAndSwap, WATCH/MULTI on
+// Redis). When another writer wins the race, EventUpdateConflict is emitted
+// and the update is retried with the new value.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeouts.
+//   - key: Cache key to update.
+//   - fn: Computes the new value; its error aborts the update and is returned as is.
+//   - opts: Call options; WithTTL, WithCallSlidingExpiration and WithUpdateRetries apply.
+//
+// Returns:
+//   - T: The value that was written.
+//   - error: ErrConflict when every attempt lost a race, ErrAtomicUnsupported when
+//     the backend cannot compare-and-swap, fn's error, or a backend, decode or encode error.
+func (h *Handler[T]) Update(ctx context.Context, key string, fn UpdateFunc[T], opts ...CallOption) (T, error) {
+	h = h.pin()
+	var zero T
+	var co callOpts
+	for _, o := range opts {
+		o(&co)
+	}
+	ttl := co.ttl
+	if ttl <= 0 {
+		ttl = h.config.defaultTTL
+	}
+	h.applySliding(ttl, co)
+	retries := defaultUpdateRetries
+	if co.updateRetries != nil {
+		retries = max(*co.updateRetries, 0)
+	}
+
+	ab, ok := h.config.backend.(AtomicBackend)
+	if !ok {
+		return zero, ErrAtomicUnsupported
+	}
+	fullKey := h.fullKey(key)
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+		next, swapped, err := h.tryUpdate(ctx, ab, fullKey, ttl, fn)
+		if err != nil {
+			return zero, err
+		}
+		if swapped {
+			return next, nil
+		}
+		h.emit(EventUpdateConflict, fullKey, nil)
+	}
+	return zero, fmt.Errorf("%w: %q after %d attempts", ErrConflict, fullKey, retries+1)
+}
+
+// tryUpdate makes one compare-and-swap attempt and reports whether it won.
+func (h *Handler[T]) tryUpdate(
+	ctx context.Context,
+	ab AtomicBackend,
+	fullKey string,
+	ttl time.Duration,
+	fn UpdateFunc[T],
+) (T, bool, error) {
+	var zero, old T
+	prev, err := ab.Get(ctx, fullKey)
+	exists := err == nil
+	switch {
+	case errors.Is(err, ErrNotFound):
+		prev = nil
+	case err != nil:
+		return zero, false, &BackendError{Op: "get", Key: fullKey, Err: err}
+	default:
+		old, err = h.decode(ctx, fullKey, prev)
+		if errors.Is(err, ErrNotFound) {
+			// Undecodable and dropped by DecodeFailureMiss or DecodeFailureDelete:
+			// start over from the zero value and replace whatever is stored.
+			exists = false
+			if prev, err = ab.Get(ctx, fullKey); errors.Is(err, ErrNotFound) {
+				prev, err = nil, nil
+			} else if err != nil {
+				err = &BackendError{Op: "get", Key: fullKey, Err: err}
+			}
+		}
+		if err != nil {
+			return zero, false, err
+		}
+	}
+
+	next, err := fn(old, exists)
+	if err != nil {
+		return zero, false, err
+	}
+	b, err := json.Marshal(next)
+	if err != nil {
+		return zero, false, &EncodeError{Key: fullKey, Err: err}
+	}
+
+	if version := h.newVersion(); version > 0 {
+		b = withVersion(version, b)
+	}
+	swapped, err := ab.CompareAndSwap(ctx, fullKey, prev, b, h.capLifetime(h.jitterTTL(fullKey, ttl)))
+	if err != nil {
+		return zero, false, &BackendError{Op: "cas", Key: fullKey, Err: err}
+	}
+	if !swapped {
+		return zero, false, nil
+	}
+	if err = h.afterWrite(ctx, fullKey); err != nil {
+		return zero, false, err
+	}
+	return next, true, nil
+}
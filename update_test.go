@@ -0,0 +1,104 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+)
+
+// contendedBackend loses every compare-and-swap, as if another pod always wrote first.
+type contendedBackend struct {
+	*cache.MemoryBackend
+}
+
+func (contendedBackend) CompareAndSwap(context.Context, string, []byte, []byte, time.Duration) (bool, error) {
+	return false, nil
+}
+
+// plainBackend hides the atomic methods of the wrapped backend.
+type plainBackend struct {
+	cache.Backend
+}
+
+// TestUpdate tests optimistic read-modify-write of cached values.
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	incr := func(old int, _ bool) (int, error) { return old + 1, nil }
+
+	t.Run("Creates and updates", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[int](cache.NewMemoryBackend())
+		v, err := h.Update(ctx, "counter", func(old int, exists bool) (int, error) {
+			if exists {
+				t.Error("Expected the first update to see a missing key")
+			}
+			return old + 1, nil
+		})
+		if err != nil || v != 1 {
+			t.Fatalf("Expected 1, got %d, %v", v, err)
+		}
+		if v, _ = h.Update(ctx, "counter", incr); v != 2 {
+			t.Errorf("Expected 2, got %d", v)
+		}
+	})
+
+	t.Run("Concurrent updates are not lost", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[int](cache.NewMemoryBackend())
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 5 {
+					if _, err := h.Update(ctx, "counter", incr, cache.WithUpdateRetries(1000)); err != nil {
+						t.Errorf("Update failed: %v", err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if r, _ := h.Get(ctx, "counter"); r.Value != 100 {
+			t.Errorf("Expected 100 increments, got %d", r.Value)
+		}
+	})
+
+	t.Run("Gives up after the retry limit", func(t *testing.T) {
+		conflicts := 0
+		h, _ := cache.NewWithBackend[int](contendedBackend{cache.NewMemoryBackend()},
+			cache.WithObserver(func(e cache.Event) {
+				if e.Kind == cache.EventUpdateConflict {
+					conflicts++
+				}
+			}),
+		)
+		_, err := h.Update(ctx, "counter", incr, cache.WithUpdateRetries(2))
+		if !errors.Is(err, cache.ErrConflict) {
+			t.Errorf("Expected ErrConflict, got %v", err)
+		}
+		if conflicts != 3 {
+			t.Errorf("Expected 3 conflicts, got %d", conflicts)
+		}
+	})
+
+	t.Run("Aborts on fn error", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[int](cache.NewMemoryBackend())
+		_ = h.Set(ctx, "counter", 5)
+		boom := errors.New("boom")
+		if _, err := h.Update(ctx, "counter", func(int, bool) (int, error) { return 0, boom }); !errors.Is(err, boom) {
+			t.Errorf("Expected boom, got %v", err)
+		}
+		if r, _ := h.Get(ctx, "counter"); r.Value != 5 {
+			t.Errorf("Expected the value to be unchanged, got %d", r.Value)
+		}
+	})
+
+	t.Run("Requires an atomic backend", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[int](plainBackend{cache.NewMemoryBackend()})
+		if _, err := h.Update(ctx, "counter", incr); !errors.Is(err, cache.ErrAtomicUnsupported) {
+			t.Errorf("Expected ErrAtomicUnsupported, got %v", err)
+		}
+	})
+}
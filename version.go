@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ---------------------------
+// Versioned writes
+// ---------------------------
+
+// versionHeader starts the header of a versioned entry: "\x00v<version>\n"
+// followed by the JSON payload. JSON never starts with a NUL byte, so
+// unversioned entries are told apart without ambiguity.
+const versionHeader = "\x00v"
+
+// VersionedBackend is implemented by backends that can write an entry only if
+// it is not older than the stored one, which WithVersionedWrites needs. Other
+// backends write versioned entries unconditionally.
+type VersionedBackend interface {
+	// SetIfNewer stores value under key unless the stored entry carries a
+	// version greater than version, and reports whether it did. Entries without
+	// a version header count as version 0.
+	SetIfNewer(ctx context.Context, key string, value []byte, version int64, ttl time.Duration) (bool, error)
+}
+
+// WithVersionedWrites stamps every entry the handler writes with a monotonic
+// version taken when its value started being produced: the call time for Set,
+// the generator start time for fills and refreshes. A write only replaces an
+// entry with an equal or lower version, so a slow background refresh or async
+// miss write cannot clobber a newer Set, even from another pod (clock skew
+// between pods limits the guarantee).
+//
+// Versioned entries have a short binary header and cannot be read by clients
+// that expect plain JSON, such as older releases and other language bindings.
+// Readers in this package accept both layouts.
+func WithVersionedWrites() Option {
+	return func(c *handlerConfig) { c.versionedWrites = true }
+}
+
+// newVersion returns the version for a value whose production starts now, or
+// 0 when versioned writes are disabled.
+func (h *Handler[T]) newVersion() int64 {
+	if !h.config.versionedWrites {
+		return 0
+	}
+	return h.core.nextVersion(h.now())
+}
+
+// nextVersion returns now in nanoseconds, or one more than the last version
+// issued if that is later, so versions from one process never repeat.
+func (c *Core) nextVersion(now time.Time) int64 {
+	for {
+		last := c.lastVersion.Load()
+		v := max(now.UnixNano(), last+1)
+		if c.lastVersion.CompareAndSwap(last, v) {
+			return v
+		}
+	}
+}
+
+// withVersion prefixes an encoded value with its version header.
+func withVersion(version int64, b []byte) []byte {
+	out := make([]byte, 0, len(versionHeader)+20+len(b))
+	out = append(out, versionHeader...)
+	out = strconv.AppendInt(out, version, 10)
+	out = append(out, '\n')
+	return append(out, b...)
+}
+
+// splitVersion returns the version and payload of a stored entry. Entries
+// without a header have version 0.
+func splitVersion(raw []byte) (int64, []byte) {
+	if !bytes.HasPrefix(raw, []byte(versionHeader)) {
+		return 0, raw
+	}
+	nl := bytes.IndexByte(raw, '\n')
+	if nl < 0 {
+		return 0, raw
+	}
+	v, err := strconv.ParseInt(string(raw[len(versionHeader):nl]), 10, 64)
+	if err != nil {
+		return 0, raw
+	}
+	return v, raw[nl+1:]
+}
+
+// write stores encoded bytes under fullKey, conditionally when version > 0
+// and the backend supports it. It reports whether the bytes were written.
+func (h *Handler[T]) write(ctx context.Context, fullKey string, b []byte, ttl time.Duration, version int64) (bool, error) {
+	if version > 0 {
+		b = withVersion(version, b)
+		if vb, ok := h.config.backend.(VersionedBackend); ok {
+			written, err := vb.SetIfNewer(ctx, fullKey, b, version, ttl)
+			if err != nil {
+				return false, &BackendError{Op: "set", Key: fullKey, Err: err}
+			}
+			if !written {
+				h.emit(EventStaleWriteDropped, fullKey, nil)
+			}
+			return written, nil
+		}
+	}
+	if err := h.config.backend.Set(ctx, fullKey, b, ttl); err != nil {
+		return false, &BackendError{Op: "set", Key: fullKey, Err: err}
+	}
+	return true, nil
+}
+
+// setIfNewerScript writes KEYS[1] = ARGV[1] with a PX of ARGV[3] (none when
+// <= 0) unless the stored entry's version header is greater than ARGV[2].
+// Versions are compared as decimal strings to keep full int64 precision.
+var setIfNewerScript = redis.NewScript(`
+local cur = redis.call('GET', KEYS[1])
+if cur and string.sub(cur, 1, 2) == '\0v' then
+  local nl = string.find(cur, '\n', 3, true)
+  if nl then
+    local stored = string.sub(cur, 3, nl - 1)
+    local incoming = ARGV[2]
+    if #stored > #incoming or (#stored == #incoming and stored > incoming) then
+      return 0
+    end
+  end
+end
+local px = tonumber(ARGV[3])
+if px > 0 then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', px)
+else
+  redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+func (b *RedisBackend) SetIfNewer(ctx context.Context, key string, value []byte, version int64, ttl time.Duration) (bool, error) {
+	n, err := setIfNewerScript.Run(ctx, b.rdb, []string{key}, value, version, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+func (m *MemoryBackend) SetIfNewer(_ context.Context, key string, value []byte, version int64, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.live(key); ok {
+		if stored, _ := splitVersion(e.value); stored > version {
+			return false, nil
+		}
+	}
+	m.store(key, value, ttl)
+	return true, nil
+}
+
+var (
+	_ VersionedBackend = (*RedisBackend)(nil)
+	_ VersionedBackend = (*MemoryBackend)(nil)
+)
@@ -0,0 +1,118 @@
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+)
+
+// TestVersionedWrites tests that slow background writes cannot clobber newer entries.
+func TestVersionedWrites(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Slow refresh loses to a newer Set", func(t *testing.T) {
+		var dropped atomic.Int32
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend(),
+			cache.WithVersionedWrites(),
+			cache.WithObserver(func(e cache.Event) {
+				if e.Kind == cache.EventStaleWriteDropped {
+					dropped.Add(1)
+				}
+			}),
+		)
+		_ = h.Set(ctx, "key", "v1")
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		_, _ = h.GetOrRefresh(ctx, "key", func(_ context.Context) (string, error) {
+			close(started)
+			<-release
+			return "refreshed from old data", nil
+		})
+		<-started
+		_ = h.Set(ctx, "key", "v2")
+		close(release)
+		_ = h.WaitIdle(ctx)
+
+		if r, _ := h.Get(ctx, "key"); r.Value != "v2" {
+			t.Errorf("Expected the newer Set to survive, got %q", r.Value)
+		}
+		if dropped.Load() != 1 {
+			t.Errorf("Expected one EventStaleWriteDropped, got %d", dropped.Load())
+		}
+	})
+
+	t.Run("Slow async miss write loses to a newer Set", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend(),
+			cache.WithVersionedWrites(),
+			cache.WithMissFillPolicy(cache.MissFillAsync),
+		)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = h.GetOrRefresh(ctx, "key", func(_ context.Context) (string, error) {
+				close(started)
+				<-release
+				return "generated before the Set", nil
+			})
+		}()
+		<-started
+		_ = h.Set(ctx, "key", "set")
+		close(release)
+		<-done
+		_ = h.WaitIdle(ctx)
+
+		if r, _ := h.Get(ctx, "key"); r.Value != "set" {
+			t.Errorf("Expected the Set to survive, got %q", r.Value)
+		}
+	})
+
+	t.Run("Reads unversioned entries", func(t *testing.T) {
+		b := cache.NewMemoryBackend()
+		h, _ := cache.NewWithBackend[string](b, cache.WithVersionedWrites())
+		_ = b.Set(ctx, "key", []byte(`"plain"`), time.Minute)
+		if r, err := h.Get(ctx, "key"); err != nil || r.Value != "plain" {
+			t.Fatalf("Expected plain, got %q, %v", r.Value, err)
+		}
+
+		_ = h.Set(ctx, "key", "versioned")
+		raw, _ := b.Get(ctx, "key")
+		if !bytes.HasPrefix(raw, []byte("\x00v")) {
+			t.Errorf("Expected a version header, got %q", raw)
+		}
+		plain, _ := cache.NewWithBackend[string](b)
+		if r, err := plain.Get(ctx, "key"); err != nil || r.Value != "versioned" {
+			t.Errorf("Expected any handler to read versioned entries, got %q, %v", r.Value, err)
+		}
+	})
+
+	t.Run("Update keeps versions", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[int](cache.NewMemoryBackend(), cache.WithVersionedWrites())
+		_ = h.Set(ctx, "counter", 1)
+		v, err := h.Update(ctx, "counter", func(old int, _ bool) (int, error) { return old + 1, nil })
+		if err != nil || v != 2 {
+			t.Errorf("Expected 2, got %d, %v", v, err)
+		}
+	})
+
+	t.Run("Redis uses a conditional script", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		b := cache.NewRedisBackend(db)
+		mock.Regexp().ExpectEvalSha(".*", []string{"key"}, `.*`, "42", "60000").SetVal(int64(0))
+		written, err := b.SetIfNewer(ctx, "key", []byte("\x00v42\n\"v\""), 42, time.Minute)
+		if err != nil || written {
+			t.Errorf("Expected the script to reject the write, got %v, %v", written, err)
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
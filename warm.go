@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ---------------------------
+// Cache warming
+// ---------------------------
+
+// defaultWarmConcurrency bounds Warm when WithWarmConcurrency is not given.
+const defaultWarmConcurrency = 8
+
+// KeyGenerator produces the value for key. Warm and Warmer use it to fill
+// many keys with one function.
+type KeyGenerator[T any] func(ctx context.Context, key string) (T, error)
+
+// WarmStats summarises one Warm pass.
+type WarmStats struct {
+	Filled  int // Keys generated and written
+	Skipped int // Keys already fresh, or being filled by another caller in this process
+	Failed  int // Keys whose generation or write failed
+}
+
+// WithWarmConcurrency limits how many keys Warm generates at once. n <= 0
+// uses the default of 8.
+func WithWarmConcurrency(n int) CallOption {
+	return func(c *callOpts) { c.warmConcurrency = n }
+}
+
+// WithCallRefreshAheadThreshold overrides the refresh-ahead threshold for a
+// single call. Warm also uses it to decide which entries are still fresh.
+func WithCallRefreshAheadThreshold(threshold float64) CallOption {
+	return func(c *callOpts) { c.refreshAheadThreshold = threshold }
+}
+
+// Warm fills keys ahead of demand, generating at most WithWarmConcurrency keys
+// at a time. An entry is skipped while it is fresh: present with more than the
+// refresh-ahead threshold of its TTL remaining (see WithRefreshAheadThreshold
+// and WithCallRefreshAheadThreshold). Every key that is filled or fails emits
+// EventWarm, and the pass ends with EventWarmCompleted.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeouts; keys not started when ctx is done count as failed, with one ctx.Err() for all of them.
+//   - keys: Cache keys to warm.
+//   - gen: Generator called with each key that needs filling.
+//   - opts: Call options; WithTTL, WithWarmConcurrency and WithCallRefreshAheadThreshold apply.
+//
+// Returns:
+//   - WarmStats: How many keys were filled, skipped and failed.
+//   - error: The per-key failures joined with errors.Join, or nil.
+func (h *Handler[T]) Warm(ctx context.Context, keys []string, gen KeyGenerator[T], opts ...CallOption) (WarmStats, error) {
+	h = h.pin()
+	var co callOpts
+	for _, o := range opts {
+		o(&co)
+	}
+	ttl := co.ttl
+	if ttl <= 0 {
+		ttl = h.config.defaultTTL
+	}
+	h.applySliding(ttl, co)
+	threshold := co.refreshAheadThreshold
+	if threshold <= 0 {
+		threshold = h.config.defaultRefreshAheadThreshold
+	}
+	workers := co.warmConcurrency
+	if workers <= 0 {
+		workers = defaultWarmConcurrency
+	}
+
+	var (
+		mu    sync.Mutex
+		stats WarmStats
+		errs  []error
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, workers)
+	)
+	record := func(filled bool, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err != nil:
+			stats.Failed++
+			errs = append(errs, err)
+		case filled:
+			stats.Filled++
+		default:
+			stats.Skipped++
+		}
+	}
+
+	for i, key := range keys {
+		if !acquire(ctx, sem) {
+			mu.Lock()
+			stats.Failed += len(keys) - i
+			errs = append(errs, ctx.Err())
+			mu.Unlock()
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			record(h.warmKey(ctx, key, ttl, threshold, gen))
+		}()
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	h.emit(EventWarmCompleted, "", err)
+	return stats, err
+}
+
+// acquire takes a slot in sem, or reports false once ctx is done.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	if ctx.Err() != nil {
+		return false // select would pick at random between a free slot and ctx
+	}
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// warmKey fills key unless it is fresh or another goroutine in this process
+// is already filling it. It reports whether the key was written.
+func (h *Handler[T]) warmKey(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	threshold float64,
+	gen KeyGenerator[T],
+) (bool, error) {
+	fullKey := h.fullKey(key)
+	if fresh, err := h.isFresh(ctx, fullKey, ttl, threshold); err != nil || fresh {
+		return false, err
+	}
+
+	unlock, ok := h.core.localLocks.TryLock(fullKey)
+	if !ok {
+		return false, nil
+	}
+	defer unlock()
+
+	version := h.newVersion()
+	v, err := gen(ctx, key)
+	if err != nil {
+		err = &GeneratorError{Key: fullKey, Err: err}
+		h.emit(EventWarm, fullKey, err)
+		return false, err
+	}
+	err = h.setVersion(ctx, key, v, ttl, version)
+	h.emit(EventWarm, fullKey, err)
+	return err == nil, err
+}
+
+// isFresh reports whether fullKey is present with more than threshold of its
+// TTL remaining. Entries without expiry are always fresh.
+func (h *Handler[T]) isFresh(ctx context.Context, fullKey string, ttl time.Duration, threshold float64) (bool, error) {
+	ref, refTTL := h.ageReference(fullKey, ttl)
+	remaining, err := h.config.backend.TTL(ctx, ref)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &BackendError{Op: "ttl", Key: ref, Err: err}
+	}
+	if remaining == 0 {
+		return true, nil
+	}
+	return float64(remaining)/float64(refTTL) > threshold, nil
+}
+
+// Warmer keeps a registered set of hot keys warm by running Warm over them
+// periodically, so they are refreshed before they expire. For keys to never
+// expire, the interval must be shorter than the refresh-ahead threshold's
+// share of the TTL (with a 0.2 threshold and a 5m TTL, under 1m).
+//
+// Progress and failures are reported through the handler's observers:
+// EventWarm per filled or failed key and EventWarmCompleted per pass.
+type Warmer[T any] struct {
+	h        *Handler[T]
+	gen      KeyGenerator[T]
+	interval time.Duration
+	opts     []CallOption
+
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// NewWarmer creates a Warmer that fills h's registered keys with gen every
+// interval. opts are passed to every Warm pass.
+func NewWarmer[T any](h *Handler[T], gen KeyGenerator[T], interval time.Duration, opts ...CallOption) *Warmer[T] {
+	return &Warmer[T]{
+		h:        h,
+		gen:      gen,
+		interval: interval,
+		opts:     opts,
+		keys:     make(map[string]struct{}),
+	}
+}
+
+// Add registers keys as hot. It may be called while Run is active.
+func (w *Warmer[T]) Add(keys ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, k := range keys {
+		w.keys[k] = struct{}{}
+	}
+}
+
+// Remove deregisters keys. Entries already cached are left to expire.
+func (w *Warmer[T]) Remove(keys ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, k := range keys {
+		delete(w.keys, k)
+	}
+}
+
+// Keys returns the registered keys in sorted order.
+func (w *Warmer[T]) Keys() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	keys := make([]string, 0, len(w.keys))
+	for k := range w.keys {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// WarmNow runs one Warm pass over the registered keys.
+func (w *Warmer[T]) WarmNow(ctx context.Context) (WarmStats, error) {
+	return w.h.Warm(ctx, w.Keys(), w.gen, w.opts...)
+}
+
+// Run warms the registered keys immediately and then every interval until ctx
+// is done. The interval is measured on the handler's clock (see TimerClock)
+// from the end of each pass. Per-pass failures are reported through the
+// observers, not returned. Run returns ctx.Err().
+func (w *Warmer[T]) Run(ctx context.Context) error {
+	for {
+		_, _ = w.WarmNow(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.h.after(w.interval):
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// TestWarm tests filling keys ahead of demand.
+func TestWarm(t *testing.T) {
+	ctx := context.Background()
+
+	ttl := cache.WithDefaultTTL(10 * time.Minute)
+	threshold := cache.WithRefreshAheadThreshold(0.2)
+	gen := func(_ context.Context, key string) (string, error) { return "value-" + key, nil }
+
+	t.Run("Fills missing and skips fresh keys", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](ttl, threshold)
+		_ = h.Set(ctx, "b", "cached")
+
+		stats, err := h.Warm(ctx, []string{"a", "b", "c"}, gen)
+		if err != nil {
+			t.Fatalf("Warm failed: %v", err)
+		}
+		if stats != (cache.WarmStats{Filled: 2, Skipped: 1}) {
+			t.Errorf("Expected 2 filled and 1 skipped, got %+v", stats)
+		}
+		if r, _ := h.Get(ctx, "b"); r.Value != "cached" {
+			t.Errorf("Expected the fresh entry to be kept, got %q", r.Value)
+		}
+
+		h.Clock.Advance(9 * time.Minute) // 10% of the TTL left, under the 0.2 threshold
+		stats, _ = h.Warm(ctx, []string{"a", "b", "c"}, gen)
+		if stats.Filled != 3 {
+			t.Errorf("Expected entries near expiry to be refilled, got %+v", stats)
+		}
+		if r, _ := h.Get(ctx, "b"); r.Value != "value-b" {
+			t.Errorf("Expected b to be regenerated, got %q", r.Value)
+		}
+	})
+
+	t.Run("Bounded concurrency", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](ttl, threshold)
+		var running atomic.Int32
+		slow := func(_ context.Context, key string) (string, error) {
+			running.Add(1)
+			<-h.Clock.After(time.Second)
+			running.Add(-1)
+			return key, nil
+		}
+
+		keys := []string{"k1", "k2", "k3", "k4", "k5", "k6", "k7", "k8"}
+		type result struct {
+			stats cache.WarmStats
+			err   error
+		}
+		done := make(chan result, 1)
+		go func() {
+			stats, err := h.Warm(ctx, keys, slow, cache.WithWarmConcurrency(2))
+			done <- result{stats, err}
+		}()
+		for range len(keys) / 2 {
+			h.Clock.BlockUntil(2) // Two generators running, the next keys waiting for a slot
+			if n := running.Load(); n != 2 {
+				t.Errorf("Expected 2 concurrent generators, got %d", n)
+			}
+			h.Clock.Advance(time.Second)
+		}
+		if r := <-done; r.err != nil || r.stats.Filled != len(keys) {
+			t.Fatalf("Expected every key filled, got %+v, %v", r.stats, r.err)
+		}
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string](ttl, threshold)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		stats, err := h.Warm(cancelled, []string{"a", "b", "c"}, gen)
+		if stats.Failed != 3 || !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected 3 failed keys and context.Canceled, got %+v, %v", stats, err)
+		}
+		if n := strings.Count(err.Error(), context.Canceled.Error()); n != 1 {
+			t.Errorf("Expected context.Canceled once, got %d times in %q", n, err)
+		}
+	})
+
+	t.Run("Reports failures", func(t *testing.T) {
+		var mu sync.Mutex
+		var events []cache.Event
+		h, _ := cachetest.NewHandler[string](ttl, threshold, cache.WithObserver(func(e cache.Event) {
+			if e.Kind == cache.EventWarm || e.Kind == cache.EventWarmCompleted {
+				mu.Lock()
+				events = append(events, e)
+				mu.Unlock()
+			}
+		}))
+		boom := errors.New("boom")
+		failing := func(_ context.Context, key string) (string, error) {
+			if key == "bad" {
+				return "", boom
+			}
+			return key, nil
+		}
+
+		stats, err := h.Warm(ctx, []string{"good", "bad"}, failing)
+		var genErr *cache.GeneratorError
+		if !errors.As(err, &genErr) || !errors.Is(err, boom) {
+			t.Errorf("Expected a GeneratorError wrapping boom, got %v", err)
+		}
+		if stats != (cache.WarmStats{Filled: 1, Failed: 1}) {
+			t.Errorf("Expected 1 filled and 1 failed, got %+v", stats)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(events) != 3 || events[2].Kind != cache.EventWarmCompleted || events[2].Err == nil {
+			t.Errorf("Expected two EventWarm and a failed EventWarmCompleted, got %v", events)
+		}
+	})
+}
+
+// TestWarmer tests periodic warming of registered hot keys.
+func TestWarmer(t *testing.T) {
+	ctx := context.Background()
+	h, _ := cachetest.NewHandler[string](cache.WithDefaultTTL(time.Minute))
+	var calls atomic.Int32
+	gen := func(_ context.Context, key string) (string, error) {
+		calls.Add(1)
+		return key, nil
+	}
+
+	w := cache.NewWarmer(h.Handler, gen, time.Hour)
+	w.Add("a", "b", "c")
+	w.Remove("c")
+	if keys := w.Keys(); len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("Expected [a b], got %v", keys)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- w.Run(runCtx) }()
+	h.Clock.BlockUntil(1) // First pass done, waiting for the interval
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("Expected Run to warm both keys at once, got %d generator calls", n)
+	}
+	if r, err := h.Get(ctx, "b"); err != nil || r.Value != "b" {
+		t.Errorf("Expected Run to warm b, got %q, %v", r.Value, err)
+	}
+
+	h.Clock.Advance(time.Hour) // The entries have expired by the next pass
+	h.Clock.BlockUntil(1)
+	if n := calls.Load(); n != 4 {
+		t.Errorf("Expected a second pass after the interval, got %d generator calls", n)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	stats, _ := w.WarmNow(ctx)
+	if stats.Skipped != 2 {
+		t.Errorf("Expected fresh keys to be skipped, got %+v", stats)
+	}
+}
@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ---------------------------
+// Write-through and write-behind
+// ---------------------------
+
+// PersistFunc writes value to the source of truth (usually a database).
+type PersistFunc[T any] func(ctx context.Context, key string, value T) error
+
+// WriteThrough persists value and then writes it to the cache, so the cache
+// never holds data the source of truth rejected. The per-key lock is held for
+// the whole update, so a concurrent miss fill in this process cannot overwrite
+// it with older data. If persisting or the cache write fails, the key is
+// deleted from the cache; the next read fills it from the source of truth.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeouts; it is passed to persist.
+//   - key: Cache key to update.
+//   - value: The new value.
+//   - persist: Writes value to the source of truth.
+//   - opts: Call options; WithTTL and WithCallSlidingExpiration apply.
+//
+// Returns:
+//   - error: A *PersistError, a cache write error, or ctx.Err() while waiting for the lock.
+//     Any error from invalidating the key is joined to it.
+func (h *Handler[T]) WriteThrough(ctx context.Context, key string, value T, persist PersistFunc[T], opts ...CallOption) error {
+	h = h.pin()
+	var co callOpts
+	for _, o := range opts {
+		o(&co)
+	}
+	ttl := co.ttl
+	if ttl <= 0 {
+		ttl = h.config.defaultTTL
+	}
+	h.applySliding(ttl, co)
+
+	fullKey := h.fullKey(key)
+	unlock, err := h.core.localLocks.LockContext(ctx, fullKey)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err = persist(ctx, key, value); err != nil {
+		err = &PersistError{Key: fullKey, Attempts: 1, Err: err}
+	} else {
+		err = h.set(ctx, key, value, ttl)
+	}
+	if err != nil {
+		if ierr := h.invalidate(ctx, key); ierr != nil {
+			return errors.Join(err, ierr)
+		}
+		return err
+	}
+	return nil
+}
+
+// WriteBehindOption configures a WriteBehind.
+type WriteBehindOption func(*writeBehindConfig)
+
+type writeBehindConfig struct {
+	queueSize  int
+	maxRetries int
+	backoff    time.Duration
+}
+
+// WithQueueSize bounds the number of writes waiting to be persisted (default
+// 1024). Set blocks while the queue is full.
+func WithQueueSize(n int) WriteBehindOption {
+	return func(c *writeBehindConfig) { c.queueSize = n }
+}
+
+// WithMaxRetries sets how many times a failed persist is retried before the
+// write is dropped (default 3).
+func WithMaxRetries(n int) WriteBehindOption {
+	return func(c *writeBehindConfig) { c.maxRetries = n }
+}
+
+// WithRetryBackoff sets the delay before the first retry; it doubles on every
+// further retry (default 100ms).
+func WithRetryBackoff(d time.Duration) WriteBehindOption {
+	return func(c *writeBehindConfig) { c.backoff = d }
+}
+
+// WriteBehind writes values to the cache immediately and persists them
+// asynchronously, in order, through a bounded queue. A persist that still
+// fails after its retries deletes the key from the cache, so readers do not
+// keep seeing data the source of truth never accepted, and emits
+// EventPersistFailed with a *PersistError.
+//
+// Each attempt runs with the handler's background refresh timeout, and retry
+// backoff is measured on the handler's clock (see TimerClock). Close persists
+// everything already queued before returning.
+type WriteBehind[T any] struct {
+	h       *Handler[T]
+	persist PersistFunc[T]
+	cfg     writeBehindConfig
+
+	mu        sync.RWMutex // Held for reading while enqueueing, so Close waits for those writes
+	closed    atomic.Bool
+	closeOnce sync.Once
+	queue     chan writeOp[T]
+	pending   bgTracker // Writes queued or being persisted
+	done      chan struct{}
+	ctx       context.Context // Cancelled when Close gives up; aborts persisting
+	abort     context.CancelFunc
+}
+
+type writeOp[T any] struct {
+	key   string
+	value T
+}
+
+// NewWriteBehind starts a WriteBehind that persists h's writes with persist.
+// Call Close to stop it.
+func NewWriteBehind[T any](h *Handler[T], persist PersistFunc[T], opts ...WriteBehindOption) *WriteBehind[T] {
+	cfg := writeBehindConfig{queueSize: 1024, maxRetries: 3, backoff: 100 * time.Millisecond}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	ctx, abort := context.WithCancel(context.Background())
+	w := &WriteBehind[T]{
+		h:       h,
+		persist: persist,
+		cfg:     cfg,
+		queue:   make(chan writeOp[T], max(cfg.queueSize, 1)),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		abort:   abort,
+	}
+	go w.run()
+	return w
+}
+
+// Set writes value to the cache and queues it to be persisted. When the cache
+// write fails nothing is queued. Set blocks while the queue is full and gives
+// up when ctx is done.
+//
+// Parameters:
+//   - ctx: Context for the cache write and for waiting on a full queue.
+//   - key: Cache key to update.
+//   - value: The new value.
+//   - opts: Call options passed to Handler.Set.
+//
+// Returns:
+//   - error: ErrWriteBehindClosed after Close, a cache write error, or ctx.Err().
+func (w *WriteBehind[T]) Set(ctx context.Context, key string, value T, opts ...CallOption) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed.Load() {
+		return ErrWriteBehindClosed
+	}
+	if err := w.h.Set(ctx, key, value, opts...); err != nil {
+		return err
+	}
+	w.pending.start()
+	select {
+	case w.queue <- writeOp[T]{key: key, value: value}:
+		return nil
+	case <-ctx.Done():
+		w.pending.done()
+		return ctx.Err()
+	}
+}
+
+// Flush blocks until every write queued so far has been persisted or dropped,
+// or ctx is done.
+func (w *WriteBehind[T]) Flush(ctx context.Context) error {
+	return w.pending.wait(ctx)
+}
+
+// Close stops accepting writes and waits until every queued write has been
+// persisted or dropped, including writes of Set calls still waiting on a full
+// queue. If ctx is done first, Close returns ctx.Err() and abandons the
+// remaining writes: pending retries stop, and every write not yet persisted is
+// deleted from the cache and reported with EventPersistFailed.
+func (w *WriteBehind[T]) Close(ctx context.Context) error {
+	w.closeOnce.Do(func() {
+		w.closed.Store(true)
+		go func() { // Sets already enqueueing hold the read lock
+			w.mu.Lock()
+			close(w.queue)
+			w.mu.Unlock()
+		}()
+	})
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		w.abort()
+		return ctx.Err()
+	}
+}
+
+func (w *WriteBehind[T]) run() {
+	defer close(w.done)
+	for op := range w.queue {
+		w.persistWithRetry(op)
+		w.pending.done()
+	}
+}
+
+// persistWithRetry persists op, retrying with exponential backoff. When every
+// attempt fails, or Close abandons the write, the cached value is invalidated.
+func (w *WriteBehind[T]) persistWithRetry(op writeOp[T]) {
+	h := w.h.pin()
+	backoff := w.cfg.backoff
+	var err error
+	attempts := 0
+	for attempts <= w.cfg.maxRetries {
+		if attempts > 0 {
+			select {
+			case <-h.after(backoff):
+			case <-w.ctx.Done():
+			}
+			backoff *= 2
+		}
+		if aerr := w.ctx.Err(); aerr != nil {
+			err = errors.Join(err, aerr)
+			break
+		}
+		attempts++
+		ctx, cancel := context.WithTimeout(w.ctx, h.config.bgRefreshTimeout)
+		err = w.persist(ctx, op.key, op.value)
+		cancel()
+		if err == nil {
+			return
+		}
+	}
+
+	fullKey := h.fullKey(op.key)
+	ctx, cancel := context.WithTimeout(context.Background(), h.config.bgRefreshTimeout)
+	defer cancel()
+	err = &PersistError{Key: fullKey, Attempts: attempts, Err: err}
+	if ierr := h.invalidate(ctx, op.key); ierr != nil {
+		err = errors.Join(err, ierr)
+	}
+	h.emit(EventPersistFailed, fullKey, err)
+}
@@ -0,0 +1,193 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/Hossein-Roshandel/cashcov"
+	"github.com/Hossein-Roshandel/cashcov/cachetest"
+)
+
+// fakeStore is a source of truth that can be made to fail.
+type fakeStore struct {
+	mu       sync.Mutex
+	rows     map[string]string
+	failures int // Remaining calls that fail
+	calls    int
+}
+
+func newFakeStore() *fakeStore { return &fakeStore{rows: make(map[string]string)} }
+
+func (s *fakeStore) persist(_ context.Context, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("db unavailable")
+	}
+	s.rows[key] = value
+	return nil
+}
+
+func (s *fakeStore) row(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rows[key]
+}
+
+// TestWriteThrough tests persisting before writing the cache.
+func TestWriteThrough(t *testing.T) {
+	ctx := context.Background()
+	h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend())
+
+	t.Run("Persists then caches", func(t *testing.T) {
+		store := newFakeStore()
+		if err := h.WriteThrough(ctx, "user:1", "alice", store.persist); err != nil {
+			t.Fatalf("WriteThrough failed: %v", err)
+		}
+		if store.row("user:1") != "alice" {
+			t.Error("Expected the value to be persisted")
+		}
+		if r, _ := h.Get(ctx, "user:1"); r.Value != "alice" {
+			t.Errorf("Expected the value to be cached, got %q", r.Value)
+		}
+	})
+
+	t.Run("Invalidates on persist failure", func(t *testing.T) {
+		store := newFakeStore()
+		store.failures = 1
+		_ = h.Set(ctx, "user:2", "old")
+
+		err := h.WriteThrough(ctx, "user:2", "new", store.persist)
+		var persistErr *cache.PersistError
+		if !errors.As(err, &persistErr) || persistErr.Key != "user:2" {
+			t.Errorf("Expected a PersistError for user:2, got %v", err)
+		}
+		if _, err = h.Get(ctx, "user:2"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected the cached value to be invalidated, got %v", err)
+		}
+	})
+}
+
+// TestWriteBehind tests asynchronous persistence through the retrying queue.
+func TestWriteBehind(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Caches at once and persists on Close", func(t *testing.T) {
+		h, _ := cache.NewWithBackend[string](cache.NewMemoryBackend())
+		store := newFakeStore()
+		w := cache.NewWriteBehind(h, store.persist, cache.WithQueueSize(2))
+
+		for _, k := range []string{"a", "b", "c", "d"} {
+			if err := w.Set(ctx, k, "v-"+k); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			if r, _ := h.Get(ctx, k); r.Value != "v-"+k {
+				t.Errorf("Expected %s to be cached immediately, got %q", k, r.Value)
+			}
+		}
+		if err := w.Close(ctx); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		for _, k := range []string{"a", "b", "c", "d"} {
+			if store.row(k) != "v-"+k {
+				t.Errorf("Expected %s to be persisted by Close", k)
+			}
+		}
+		if err := w.Set(ctx, "e", "v"); !errors.Is(err, cache.ErrWriteBehindClosed) {
+			t.Errorf("Expected ErrWriteBehindClosed, got %v", err)
+		}
+	})
+
+	t.Run("Retries failed persists", func(t *testing.T) {
+		h, _ := cachetest.NewHandler[string]()
+		store := newFakeStore()
+		store.failures = 2
+		w := cache.NewWriteBehind(h.Handler, store.persist, cache.WithMaxRetries(2), cache.WithRetryBackoff(time.Second))
+		defer func() { _ = w.Close(ctx) }()
+
+		_ = w.Set(ctx, "k", "v")
+		h.Clock.BlockUntil(1)
+		h.Clock.Advance(time.Second)
+		h.Clock.BlockUntil(1)
+		h.Clock.Advance(2 * time.Second) // Backoff doubles
+		if err := w.Flush(ctx); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		if store.row("k") != "v" || store.calls != 3 {
+			t.Errorf("Expected success on the third attempt, got %d calls", store.calls)
+		}
+	})
+
+	t.Run("Invalidates after the last retry", func(t *testing.T) {
+		events := make(chan cache.Event, 1)
+		h, _ := cachetest.NewHandler[string](cache.WithObserver(func(e cache.Event) {
+			if e.Kind == cache.EventPersistFailed {
+				events <- e
+			}
+		}))
+		store := newFakeStore()
+		store.failures = 10
+		w := cache.NewWriteBehind(h.Handler, store.persist, cache.WithMaxRetries(1), cache.WithRetryBackoff(time.Second))
+
+		_ = w.Set(ctx, "k", "v")
+		h.Clock.BlockUntil(1)
+		h.Clock.Advance(time.Second)
+		_ = w.Close(ctx)
+
+		e := <-events
+		var persistErr *cache.PersistError
+		if !errors.As(e.Err, &persistErr) || persistErr.Attempts != 2 {
+			t.Errorf("Expected a PersistError after 2 attempts, got %v", e.Err)
+		}
+		if _, err := h.Get(ctx, "k"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("Expected the unpersisted value to be invalidated, got %v", err)
+		}
+	})
+
+	t.Run("Close gives up with its context", func(t *testing.T) {
+		events := make(chan cache.Event, 3)
+		h, _ := cachetest.NewHandler[string](cache.WithObserver(func(e cache.Event) {
+			if e.Kind == cache.EventPersistFailed {
+				events <- e
+			}
+		}))
+		started := make(chan struct{}, 3)
+		stuck := func(ctx context.Context, _, _ string) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		w := cache.NewWriteBehind(h.Handler, stuck, cache.WithQueueSize(1))
+		_ = w.Set(ctx, "a", "v")
+		<-started
+		_ = w.Set(ctx, "b", "v") // Fills the queue
+		setDone := make(chan error, 1)
+		go func() { setDone <- w.Set(ctx, "c", "v") }() // Waits on the full queue
+
+		closeCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := w.Close(closeCtx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected Close to return context.Canceled, got %v", err)
+		}
+		if err := <-setDone; err != nil && !errors.Is(err, cache.ErrWriteBehindClosed) {
+			t.Errorf("Expected the waiting Set to finish, got %v", err)
+		}
+		if err := w.Flush(ctx); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		for _, k := range []string{"a", "b"} {
+			e := <-events
+			if !errors.Is(e.Err, context.Canceled) {
+				t.Errorf("Expected the abandoned write to report context.Canceled, got %v", e.Err)
+			}
+			if _, err := h.Get(ctx, k); !errors.Is(err, cache.ErrNotFound) {
+				t.Errorf("Expected the abandoned write of %s to be invalidated, got %v", k, err)
+			}
+		}
+	})
+}